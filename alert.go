@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// etaAlerter flags pools whose ETA exceeds -eta-alert. It posts an event to
+// its webhook when a pool first goes over the limit (and again if it drops
+// back under and goes over once more), and remembers that the limit was
+// exceeded for -eta-alert-exit.
+type etaAlerter struct {
+	limit    time.Duration
+	webhook  *webhookReporter // nil unless -eta-alert-webhook
+	over     map[string]bool  // keyed by alias+CmdLine
+	exceeded bool
+	exit     bool // -eta-alert-exit: stop once the limit is exceeded
+}
+
+// errETAExceeded stops a run with -eta-alert-exit.
+var errETAExceeded = errors.New("ETA exceeds the -eta-alert limit")
+
+func newETAAlerter(limit time.Duration, webhookURL string, exit bool) *etaAlerter {
+	a := &etaAlerter{limit: limit, over: map[string]bool{}, exit: exit}
+	if webhookURL != "" {
+		a.webhook = newWebhookReporter(webhookURL, jsonShape{})
+	}
+	return a
+}
+
+func (a *etaAlerter) report(r *pollReport) error {
+	for _, s := range r.active() {
+		key := stateKey(r.Alias, s.CmdLine)
+		over := exceedsETA(s, a.limit)
+		if over {
+			a.exceeded = true
+		}
+		if over && !a.over[key] && a.webhook != nil {
+			ev := newEvent(eventETAAlert, r, s)
+			limit := a.limit.Seconds()
+			ev.LimitSeconds = &limit
+			if err := postJSON(a.webhook.client, a.webhook.url, ev); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: eta alert: %v\n", err)
+			}
+		}
+		a.over[key] = over
+	}
+	return nil
+}
+
+// tripped reports whether the run should stop with errETAExceeded.
+func (a *etaAlerter) tripped() bool {
+	return a != nil && a.exit && a.exceeded
+}
+
+// exceedsETA reports whether the pool is projected to finish later than
+// limit from now.
+func exceedsETA(s decomStatus, limit time.Duration) bool {
+	return limit > 0 && s.HasETA && s.ETA > limit
+}
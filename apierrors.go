@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+const madminModule = "github.com/minio/madmin-go/v3"
+
+// versionMismatchError explains a failure caused by the server speaking a
+// different admin API than the madmin-go this binary was built with, which
+// otherwise surfaces as a cryptic decode error.
+type versionMismatchError struct {
+	err           error
+	serverVersion string // empty if ServerInfo failed too
+}
+
+func (e *versionMismatchError) Error() string {
+	msg := fmt.Sprintf("server response doesn't match this build's admin API (%s %s, API %s): %v",
+		madminModule, madminVersion(), madmin.AdminAPIVersion, e.err)
+	if e.serverVersion != "" {
+		msg += fmt.Sprintf("; server is running %s", e.serverVersion)
+	}
+	return msg + "; use a decom-eta build with a madmin-go release compatible with the server"
+}
+
+func (e *versionMismatchError) Unwrap() error {
+	return e.err
+}
+
+// isVersionMismatch reports whether err looks like a client/server admin API
+// incompatibility rather than a connectivity or permission problem.
+func isVersionMismatch(err error) bool {
+	var apiErr madmin.ErrorResponse
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == "XMinioAdminVersionMismatch" || apiErr.Code == "NotImplemented"
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// accessDeniedError explains a permission failure with the admin actions the
+// call needs, as the server's own message doesn't say.
+type accessDeniedError struct {
+	err     error
+	actions []string // any one of them grants access
+}
+
+func (e *accessDeniedError) Error() string {
+	actions := make([]string, len(e.actions))
+	for i, a := range e.actions {
+		actions[i] = strconv.Quote(a)
+	}
+	return fmt.Sprintf("access denied: %s; the alias's credentials need a policy allowing %s, such as the built-in consoleAdmin policy or a custom one attached with 'mc admin policy attach'",
+		strings.TrimSuffix(e.err.Error(), "."), strings.Join(actions, " or "))
+}
+
+func (e *accessDeniedError) Unwrap() error {
+	return e.err
+}
+
+// isAccessDenied reports whether the server rejected the credentials'
+// permissions. When the error body can't be parsed, madmin puts the HTTP
+// status in Code instead.
+func isAccessDenied(err error) bool {
+	var apiErr madmin.ErrorResponse
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == "AccessDenied" || strings.HasPrefix(apiErr.Code, "403 ")
+}
+
+// consoleEndpointError explains that the alias points at the MinIO Console
+// rather than the server's S3/admin API, which otherwise surfaces as a
+// decode error on the Console's web page.
+type consoleEndpointError struct {
+	err      error
+	endpoint string
+}
+
+func (e *consoleEndpointError) Error() string {
+	return fmt.Sprintf("%s is the MinIO Console, not the S3/admin API (%v); the admin API can't be reached through the Console, so point the alias at the server's API port instead (9000 by default, the one 'mc admin info' works with)",
+		e.endpoint, e.err)
+}
+
+func (e *consoleEndpointError) Unwrap() error {
+	return e.err
+}
+
+// mayBeConsole reports whether err is what the admin API gets back from a
+// Console: a web page where JSON was expected, or a missing route.
+func mayBeConsole(err error) bool {
+	var apiErr madmin.ErrorResponse
+	if errors.As(err, &apiErr) && (strings.HasPrefix(apiErr.Code, "404 ") || strings.HasPrefix(apiErr.Code, "405 ")) {
+		return true
+	}
+	return isVersionMismatch(err)
+}
+
+// isConsole asks endpoint for the Console's login options, which only a
+// Console serves. transport is the admin client's, so the probe presents the
+// same client certificate and -header values.
+func isConsole(endpoint *url.URL, transport http.RoundTripper) bool {
+	client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
+	resp, err := client.Get(endpoint.JoinPath("/api/v1/login").String())
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	var login struct {
+		LoginStrategy string `json:"loginStrategy"`
+	}
+	return resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&login) == nil && login.LoginStrategy != ""
+}
+
+// madminVersion is the madmin-go version compiled into this binary.
+func madminVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range bi.Deps {
+			if dep.Path == madminModule {
+				return dep.Version
+			}
+		}
+	}
+	return "(unknown version)"
+}
+
+// serverVersion asks the cluster which MinIO release it runs, for context in
+// error messages. It returns "" if that fails as well.
+func serverVersion(client *madmin.AdminClient) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	info, err := client.ServerInfo(ctx)
+	if err != nil {
+		return ""
+	}
+	for _, srv := range info.Servers {
+		if srv.Version != "" {
+			return srv.Version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// fillSample is the first observation of a pool that is receiving data.
+type fillSample struct {
+	Time       time.Time
+	TotalSize  int64
+	FreeSpace  int64
+	DrainStart time.Time // of the drain in progress when it was taken
+}
+
+// capacityWarning flags a pool that is projected to run low on space before
+// the drains in progress finish.
+type capacityWarning struct {
+	Pool          int // 1-based
+	FillRate      float64
+	ProjectedFree int64 // may be negative
+	TotalSize     int64
+	At            time.Duration // from now, when the drains are expected to finish
+	UntilFull     time.Duration // from now, at FillRate
+}
+
+// fillTracker extrapolates how fast the pools that aren't draining are
+// filling up, measured from the first poll of this watch.
+type fillTracker struct {
+	minFree float64 // fraction of a pool's size
+	first   map[string]fillSample
+}
+
+func newFillTracker(minFreePercent float64) *fillTracker {
+	return &fillTracker{minFree: minFreePercent / 100, first: map[string]fillSample{}}
+}
+
+// check returns a warning for every receiving pool whose free space, at its
+// current fill rate, drops below the threshold before the slowest draining
+// pool is due to finish.
+func (f *fillTracker) check(alias string, pools []madmin.PoolStatus, statuses []decomStatus, now time.Time) []capacityWarning {
+	var finish time.Duration
+	var drainStart time.Time
+	for _, s := range statuses {
+		if s.State != stateActive {
+			continue
+		}
+		if s.HasETA && s.ETA > finish {
+			finish = s.ETA
+		}
+		if s.StartTime.After(drainStart) {
+			drainStart = s.StartTime
+		}
+	}
+
+	var warnings []capacityWarning
+	for _, pool := range pools {
+		d := pool.Decommission
+		if d == nil || d.TotalSize == 0 {
+			continue
+		}
+		if !d.StartTime.IsZero() && decomState(d) == stateActive {
+			continue
+		}
+		key := stateKey(alias, pool.CmdLine)
+		first, ok := f.first[key]
+		// Start over when the pool changed size or another drain began,
+		// as the old rate no longer applies.
+		if !ok || first.TotalSize != d.TotalSize || !first.DrainStart.Equal(drainStart) {
+			f.first[key] = fillSample{Time: now, TotalSize: d.TotalSize, FreeSpace: d.CurrentSize, DrainStart: drainStart}
+			continue
+		}
+
+		elapsed := now.Sub(first.Time).Seconds()
+		rate := float64(first.FreeSpace-d.CurrentSize) / elapsed
+		if finish == 0 || elapsed <= 10 || rate <= 0 {
+			continue
+		}
+		projected := d.CurrentSize - int64(rate*finish.Seconds())
+		if float64(projected) < f.minFree*float64(d.TotalSize) {
+			warnings = append(warnings, capacityWarning{
+				Pool:          pool.ID + 1,
+				FillRate:      rate,
+				ProjectedFree: projected,
+				TotalSize:     d.TotalSize,
+				At:            finish,
+				UntilFull:     time.Duration(float64(d.CurrentSize)/rate) * time.Second,
+			})
+		}
+	}
+	return warnings
+}
+
+// clusterSpace is the free space of the whole cluster now and once the pools
+// being decommissioned have been drained and removed, with the data left on
+// them moved to the others.
+type clusterSpace struct {
+	Total      int64 `json:"totalBytes"`
+	Free       int64 `json:"freeBytes"`
+	TotalAfter int64 `json:"totalAfterBytes"`
+	FreeAfter  int64 `json:"freeAfterBytes"` // negative if the data doesn't fit
+	Removed    []int `json:"removedPools"`   // 1-based
+}
+
+// newClusterSpace adds up the pool sizes from the listing. It returns nil
+// when no pool is draining.
+func newClusterSpace(pools []madmin.PoolStatus) *clusterSpace {
+	cs := &clusterSpace{}
+	for _, pool := range pools {
+		d := pool.Decommission
+		if d == nil || d.TotalSize == 0 {
+			continue
+		}
+		cs.Total += d.TotalSize
+		cs.Free += d.CurrentSize
+		if !d.StartTime.IsZero() && decomState(d) == stateActive {
+			cs.Removed = append(cs.Removed, pool.ID+1)
+			cs.FreeAfter -= d.TotalSize - d.CurrentSize
+			continue
+		}
+		cs.TotalAfter += d.TotalSize
+		cs.FreeAfter += d.CurrentSize
+	}
+	if len(cs.Removed) == 0 {
+		return nil
+	}
+	return cs
+}
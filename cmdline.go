@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxEndpoints bounds ellipsis expansion so a malformed command line can't
+// make us allocate without limit.
+const maxEndpoints = 1 << 16
+
+// ellipsisRe matches MinIO's range syntax, e.g. {1...4}, {01...16}, {a...f}.
+var ellipsisRe = regexp.MustCompile(`\{([0-9a-fA-F]+)\.\.\.([0-9a-fA-F]+)\}`)
+
+// poolTopology summarizes the endpoints described by a pool's command line.
+type poolTopology struct {
+	Servers []string // unique host[:port], in order of appearance
+	Drives  int
+}
+
+func (t poolTopology) String() string {
+	return fmt.Sprintf("%s, %s",
+		plural(len(t.Servers), "server", "servers"),
+		plural(t.Drives, "drive", "drives"))
+}
+
+// serverList names the servers, or the first few of many.
+func (t poolTopology) serverList() string {
+	const shown = 3
+	if len(t.Servers) <= shown+1 {
+		return strings.Join(t.Servers, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(t.Servers[:shown], ", "), len(t.Servers)-shown)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// parseCmdLine expands the server spec of a pool (one or more
+// space-separated endpoint patterns) into its servers and drive count.
+func parseCmdLine(cmdLine string) (poolTopology, error) {
+	var t poolTopology
+	seen := map[string]bool{}
+	for _, arg := range strings.Fields(cmdLine) {
+		endpoints, err := expandEllipses(arg)
+		if err != nil {
+			return poolTopology{}, err
+		}
+		for _, ep := range endpoints {
+			host := "localhost"
+			if strings.Contains(ep, "://") {
+				u, err := url.Parse(ep)
+				if err != nil {
+					return poolTopology{}, fmt.Errorf("parse endpoint %q: %w", ep, err)
+				}
+				host = u.Host
+			}
+			if !seen[host] {
+				seen[host] = true
+				t.Servers = append(t.Servers, host)
+			}
+			t.Drives++
+		}
+	}
+	if t.Drives == 0 {
+		return poolTopology{}, fmt.Errorf("no endpoints in %q", cmdLine)
+	}
+	return t, nil
+}
+
+// expandEllipses returns every string described by pattern, expanding each
+// {start...end} range left to right.
+func expandEllipses(pattern string) ([]string, error) {
+	loc := ellipsisRe.FindStringSubmatchIndex(pattern)
+	if loc == nil {
+		return []string{pattern}, nil
+	}
+
+	values, err := ellipsisRange(pattern[loc[2]:loc[3]], pattern[loc[4]:loc[5]])
+	if err != nil {
+		return nil, err
+	}
+	prefix, rest := pattern[:loc[0]], pattern[loc[1]:]
+
+	suffixes, err := expandEllipses(rest)
+	if err != nil {
+		return nil, err
+	}
+	if len(values)*len(suffixes) > maxEndpoints {
+		return nil, fmt.Errorf("%q expands to more than %d endpoints", pattern, maxEndpoints)
+	}
+
+	out := make([]string, 0, len(values)*len(suffixes))
+	for _, v := range values {
+		for _, s := range suffixes {
+			out = append(out, prefix+v+s)
+		}
+	}
+	return out, nil
+}
+
+// ellipsisRange expands one range. Bounds are decimal unless either one
+// contains a hex letter; a leading zero on start means zero-padded output.
+func ellipsisRange(start, end string) ([]string, error) {
+	base := 10
+	if strings.ContainsAny(start+end, "abcdefABCDEF") {
+		base = 16
+	}
+	lo, err := strconv.ParseUint(start, base, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range start %q: %w", start, err)
+	}
+	hi, err := strconv.ParseUint(end, base, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range end %q: %w", end, err)
+	}
+	if lo > hi {
+		return nil, fmt.Errorf("invalid range {%s...%s}", start, end)
+	}
+	if hi-lo >= maxEndpoints {
+		return nil, fmt.Errorf("range {%s...%s} is too large", start, end)
+	}
+
+	width := 0
+	if len(start) > 1 && start[0] == '0' {
+		width = len(start)
+	}
+	values := make([]string, 0, hi-lo+1)
+	for i := lo; i <= hi; i++ {
+		v := strconv.FormatUint(i, base)
+		if len(v) < width {
+			v = strings.Repeat("0", width-len(v)) + v
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
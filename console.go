@@ -0,0 +1,869 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/madmin-go/v3"
+	"golang.org/x/text/message"
+)
+
+// Console output formats.
+const (
+	formatText   = "text"
+	formatJSON   = "json"
+	formatJSONL  = "jsonl"
+	formatInflux = "influx"
+	formatProto  = "proto"
+)
+
+// outputOptions controls how the console output is rendered.
+type outputOptions struct {
+	format           string
+	precision        int // decimals in percentages and speeds
+	roundETA         bool
+	json             jsonShape        // -compact-json and -json-fields
+	printer          *message.Printer // -locale number formatting; nil for the default
+	noETA            bool             // do not estimate completion times at all
+	rawBytes         bool             // exact byte counts instead of humanized sizes
+	onlyChanges      bool             // -jsonl: skip pools whose CurrentSize didn't change
+	follow           bool             // one appended line per change instead of a redraw
+	color            bool             // ANSI colors in -follow lines
+	fixedWidth       bool             // pad numbers so they keep their place between polls
+	summaryOnly      bool             // one line for the cluster instead of the pools
+	glyphs           *glyphSet        // -glyphs: one character per pool instead; nil if not set
+	noBanner         bool             // only the pool blocks, for embedding
+	verbose          bool             // add the topology of draining pools
+	etaAlert         time.Duration    // flag ETAs beyond this, if set
+	costPerGB        float64          // -cost-per-gb: price the data left to move, if set
+	head, tail       int              // show only the first/last this many pools, if set
+	timeStyle        string           // how durations are phrased
+	aggregateMode    string           // how the ETA of several drains is combined
+	summarizeCmdLine bool
+	quiet            bool
+	list             bool
+	fields           bool // the raw status fields of each pool instead
+}
+
+// consoleReporter writes each poll to stdout, as text or JSON.
+type consoleReporter struct {
+	out   outputOptions
+	state *stateFile // the previous run, for -diff-since
+	// lastSize is the CurrentSize last printed per pool, for -only-changes.
+	lastSize map[string]int64
+	// frames counts the polls of each alias, for the -glyphs spinner.
+	frames map[string]int
+}
+
+func (c *consoleReporter) report(r *pollReport) error {
+	switch c.out.format {
+	case formatJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(encodedReport(newJSONReport(r), c.out.json))
+	case formatJSONL:
+		enc := json.NewEncoder(os.Stdout)
+		for _, s := range r.changed() {
+			if c.out.onlyChanges {
+				key := stateKey(r.Alias, s.CmdLine)
+				if last, ok := c.lastSize[key]; ok && last == s.CurrentSize {
+					continue
+				}
+				c.lastSize[key] = s.CurrentSize
+			}
+			if err := enc.Encode(encodedPool(newJSONPool(r, s), c.out.json)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatInflux:
+		for _, s := range r.active() {
+			fmt.Println(influxLine(r.Alias, r.Time, s))
+		}
+		return nil
+	case formatProto:
+		return writeProto(os.Stdout, r)
+	}
+	if c.out.follow {
+		c.printFollow(r)
+		return nil
+	}
+	c.printText(r)
+	return nil
+}
+
+// printSummary prints one line for the whole cluster: the draining pools'
+// combined progress and speed, and when they should all be done.
+func (c *consoleReporter) printSummary(r *pollReport) {
+	active := r.active()
+	if len(active) == 0 {
+		fmt.Printf("%s: no pools draining\n", r.Alias)
+		return
+	}
+	var done, total, speed float64
+	for _, s := range active {
+		speed += s.Speed
+		if s.Basis == basisObjects {
+			done += float64(s.ObjectsDone)
+			total += float64(s.TotalObjects)
+		} else {
+			done += float64(s.BytesFreed)
+			total += float64(s.InitialUsed)
+		}
+	}
+	basis := active[0].Basis
+	line := fmt.Sprintf("%s: %s draining", r.Alias, plural(len(active), "pool", "pools"))
+	if total > 0 && done > 0 {
+		if basis == basisObjects {
+			line += fmt.Sprintf(", %s / %s objects moved", c.out.comma(int64(done)), c.out.comma(int64(total)))
+		} else {
+			line += fmt.Sprintf(", %s / %s freed", c.out.ibytes(uint64(done)), c.out.ibytes(uint64(total)))
+		}
+		line += fmt.Sprintf(" (%s) at %s", c.out.percent(100*done/total), c.out.formatSpeed(basis, speed))
+	}
+	if eta, ok := aggregateETA(active, c.out.aggregateMode); ok {
+		eta = c.out.displayETA(eta)
+		line += fmt.Sprintf(", ETA %s (%s remaining)", r.Time.Add(eta).Format(time.RFC3339), c.out.duration(eta))
+	}
+	fmt.Println(line)
+}
+
+// printFollow appends a line for every draining pool whose free space changed
+// since the previous poll, and one when a pool stops draining, building a
+// log in the terminal's scrollback.
+func (c *consoleReporter) printFollow(r *pollReport) {
+	stamp := r.Time.Format(time.RFC3339)
+	for _, s := range r.Pools {
+		key := stateKey(r.Alias, s.CmdLine)
+		last, seen := c.lastSize[key]
+		if s.State != stateActive {
+			if seen {
+				fmt.Printf("%s pool #%d: decommission %s\n", stamp, s.ID+1, s.State)
+				delete(c.lastSize, key)
+			}
+			continue
+		}
+		if seen && last == s.CurrentSize {
+			continue
+		}
+		c.lastSize[key] = s.CurrentSize
+		delta := ""
+		if seen {
+			delta = " " + c.out.delta(s.CurrentSize-last)
+		}
+
+		if !s.HasProgress {
+			toMove := c.out.ibytes(uint64(s.InitialUsed))
+			if s.Basis == basisObjects {
+				toMove = c.out.comma(s.TotalObjects-s.ObjectsDone) + " objects"
+			}
+			fmt.Printf("%s pool #%d: starting, %s to move\n", stamp, s.ID+1, toMove)
+			continue
+		}
+		done := c.out.pad(c.out.ibytes(uint64(s.BytesFreed)), c.out.bytesWidth()) + " freed" + delta
+		if s.Basis == basisObjects {
+			done = c.out.comma(s.ObjectsDone) + " / " + c.out.comma(s.TotalObjects) + " objects moved"
+		}
+		line := fmt.Sprintf("%s pool #%d: %s (%s) at %s", stamp, s.ID+1, done,
+			c.out.pad(c.out.percent(s.Progress*100), c.out.percentWidth()),
+			c.out.pad(c.out.formatSpeed(s.Basis, s.Speed), c.out.bytesWidth()+4))
+		if s.HasETA {
+			line += ", " + c.out.pad(c.out.duration(c.out.displayETA(s.ETA)), durationWidth) + " remaining"
+		}
+		// A line is printed on a change in free space, so that is the
+		// change shown whatever the basis.
+		if s.Basis == basisObjects && seen {
+			line += ", free space" + delta
+		}
+		fmt.Println(line)
+	}
+}
+
+func (c *consoleReporter) printText(r *pollReport) {
+	now := r.Time
+	active := r.active()
+	if c.out.summaryOnly {
+		c.printSummary(r)
+		return
+	}
+	if c.out.glyphs != nil {
+		c.printGlyphs(r)
+		return
+	}
+	if c.out.noBanner {
+		c.printBlocks(r)
+		return
+	}
+	if r.Server != nil {
+		fmt.Println(r.Server)
+		fmt.Println()
+	}
+	shown, hidden := c.out.pageOf(len(active))
+	for _, s := range active[shown.from:shown.to] {
+		c.printPool(r, s)
+		fmt.Println()
+	}
+
+	c.out.printHidden(hidden)
+	if len(active) > 1 {
+		if eta, ok := aggregateETA(active, c.out.aggregateMode); ok {
+			eta = c.out.displayETA(eta)
+			how := "latest of the pool ETAs"
+			if c.out.aggregateMode == aggregateCombined {
+				how = "data left on all pools at their combined speed"
+			}
+			fmt.Printf("All pools: ETA %s (%s remaining, %s)\n",
+				now.Add(eta).Format(time.RFC3339), c.out.duration(eta), how)
+		}
+		fmt.Printf("Warning: %d pools are being decommissioned at once. MinIO recommends draining one pool at a time: concurrent drains compete for the same drives and network, and are slower and riskier.\n", len(active))
+		fmt.Println()
+	}
+	for _, w := range r.Capacity {
+		if w.ProjectedFree < 0 {
+			fmt.Printf("Warning: pool #%d is filling at %s and would run out of space in %s, before the drain finishes in %s\n",
+				w.Pool, c.out.formatSpeed(basisBytes, w.FillRate),
+				c.out.duration(w.UntilFull), c.out.duration(c.out.displayETA(w.At)))
+			continue
+		}
+		fmt.Printf("Warning: pool #%d is filling at %s and projected to have %s free (%s) when the drain finishes in %s\n",
+			w.Pool, c.out.formatSpeed(basisBytes, w.FillRate),
+			c.out.ibytes(uint64(w.ProjectedFree)),
+			c.out.percent(100*float64(w.ProjectedFree)/float64(w.TotalSize)),
+			c.out.duration(c.out.displayETA(w.At)))
+	}
+	if len(r.Capacity) > 0 {
+		fmt.Println()
+	}
+	if cs := r.Cluster; cs != nil && len(active) > 0 {
+		fmt.Printf("Cluster free space: %s of %s (%s) now", c.out.ibytes(uint64(cs.Free)), c.out.ibytes(uint64(cs.Total)),
+			c.out.percent(100*float64(cs.Free)/float64(cs.Total)))
+		if cs.FreeAfter < 0 {
+			fmt.Printf("\nWarning: the data left on %s doesn't fit on the rest of the cluster, %s short\n",
+				poolNumbers(cs.Removed), c.out.ibytes(uint64(-cs.FreeAfter)))
+		} else {
+			verb := "are"
+			if len(cs.Removed) == 1 {
+				verb = "is"
+			}
+			fmt.Printf(", %s of %s (%s) once %s %s removed\n", c.out.ibytes(uint64(cs.FreeAfter)), c.out.ibytes(uint64(cs.TotalAfter)),
+				c.out.percent(100*float64(cs.FreeAfter)/float64(cs.TotalAfter)), poolNumbers(cs.Removed), verb)
+		}
+		fmt.Println()
+	}
+
+	for _, s := range r.Finished {
+		c.printFinished(s)
+		fmt.Println()
+	}
+
+	switch {
+	case len(active) > 0:
+	case r.Listed == 0:
+		fmt.Println("The cluster reported no pools. Decommission status is only available on deployments using server pools.")
+	case r.Kept == 0:
+		fmt.Printf("None of the %s reported by the cluster match the filters.\n", plural(r.Listed, "pool", "pools"))
+	case r.ProgressHidden > 0:
+		fmt.Printf("No draining pools within the -progress-above/-progress-below range (%s hidden).\n",
+			plural(r.ProgressHidden, "pool", "pools"))
+	default:
+		fmt.Println("No pools are currently being decommissioned.")
+	}
+
+	if r.Plan != nil {
+		c.printPlan(r.Plan, now)
+	}
+}
+
+// printBlocks is printText for -no-banner: the pool blocks alone, with a
+// blank line between them but none around them, and nothing at all when no
+// pool is draining.
+func (c *consoleReporter) printBlocks(r *pollReport) {
+	active := r.active()
+	shown, _ := c.out.pageOf(len(active))
+	n := 0
+	for _, s := range active[shown.from:shown.to] {
+		if n++; n > 1 {
+			fmt.Println()
+		}
+		c.printPool(r, s)
+	}
+	for _, s := range r.Finished {
+		if n++; n > 1 {
+			fmt.Println()
+		}
+		c.printFinished(s)
+	}
+}
+
+// printPool prints the status block of a draining pool.
+func (c *consoleReporter) printPool(r *pollReport, s decomStatus) {
+	now := r.Time
+	fmt.Printf("Pool #%d: %s\n", s.ID+1, c.out.poolLabel(s.CmdLine))
+	fmt.Printf("  Started: %s (%s)\n", s.StartTime.Format(time.RFC3339), c.out.ago(s.StartTime, now))
+	if s.Restarted {
+		fmt.Println("  Decommission restarted: earlier progress discarded from the estimates")
+	}
+	if s.Regressed > 0 {
+		fmt.Printf("  Warning: free space fell by %s from its peak, so data may be moving back onto the pool; progress is held at the peak\n",
+			c.out.ibytes(uint64(s.Regressed)))
+	}
+
+	if s.HasProgress {
+		if s.Basis == basisObjects {
+			fmt.Printf("  Progress: %s / %s objects moved (%s)",
+				c.out.comma(s.ObjectsDone),
+				c.out.comma(s.TotalObjects),
+				c.out.percent(s.Progress*100))
+			if s.ObjectsFailed > 0 {
+				fmt.Printf(", %s failed", c.out.comma(s.ObjectsFailed))
+			}
+			fmt.Println()
+		} else {
+			fmt.Printf("  Progress: %s / %s freed (%s)\n",
+				c.out.pad(c.out.ibytes(uint64(s.BytesFreed)), c.out.bytesWidth()),
+				c.out.ibytes(uint64(s.InitialUsed)),
+				c.out.pad(c.out.percent(s.Progress*100), c.out.percentWidth()))
+		}
+		fmt.Printf("  Current usage: %s / %s (%s)\n",
+			c.out.pad(c.out.ibytes(uint64(s.UsedNow)), c.out.bytesWidth()),
+			c.out.ibytes(uint64(s.TotalSize)),
+			c.out.pad(c.out.percent(100*float64(s.UsedNow)/float64(s.TotalSize)), c.out.percentWidth()))
+		c.printRaw(s, r.Sets[s.ID])
+		if c.out.verbose {
+			c.out.printTopology(s.CmdLine, s.TotalSize, s.UsedNow)
+		}
+		c.printSets(r.Sets[s.ID])
+		c.printDrives(r.Drives[s.ID])
+		if s.WindowStart.IsZero() {
+			fmt.Printf("  Speed: %s\n", c.out.pad(c.out.formatSpeed(s.Basis, s.Speed), c.out.bytesWidth()+4))
+		} else {
+			fmt.Printf("  Speed: %s (since %s)\n", c.out.pad(c.out.formatSpeed(s.Basis, s.Speed), c.out.bytesWidth()+4), formatSince(s.WindowStart))
+		}
+
+		if s.HasETA {
+			eta := c.out.displayETA(s.ETA)
+			fmt.Printf("  ETA: %s (%s remaining",
+				now.Add(eta).Format(time.RFC3339),
+				c.out.duration(eta))
+			if s.HistoryRuns > 0 {
+				fmt.Printf(", from cluster history: %s at %s, blended with this drain's speed",
+					plural(s.HistoryRuns, "past drain", "past drains"), c.out.formatSpeed(s.Basis, s.HistorySpeed))
+			}
+			fmt.Print(")")
+			if exceedsETA(s, c.out.etaAlert) {
+				fmt.Printf(" !! over the %s limit", c.out.duration(c.out.etaAlert))
+			}
+			fmt.Println()
+		}
+		if s.HasRange {
+			high := "unbounded"
+			if s.ETAHigh > 0 {
+				high = c.out.duration(c.out.displayETA(s.ETAHigh))
+			}
+			fmt.Printf("  ETA range: %s to %s (speed ±1 standard deviation over %s)\n",
+				c.out.duration(c.out.displayETA(s.ETALow)), high,
+				plural(s.Intervals, "interval", "intervals"))
+		}
+		if s.HasRecent {
+			eta := c.out.displayETA(s.RecentETA)
+			fmt.Printf("  Recent ETA: %s (%s remaining at %s over the last 25%% of the run, %s)\n",
+				now.Add(eta).Format(time.RFC3339),
+				c.out.duration(eta),
+				c.out.formatSpeed(s.Basis, s.RecentSpeed),
+				s.trend())
+		}
+		if s.HasObjectETA {
+			eta := c.out.displayETA(s.ObjectETA)
+			fmt.Printf("  Object ETA: %s (%s remaining at %s, %s of objects moved)\n",
+				now.Add(eta).Format(time.RFC3339),
+				c.out.duration(eta),
+				c.out.formatSpeed(basisObjects, s.ObjectSpeed),
+				c.out.percent(100*s.ObjectProgress))
+			if d := s.divergence(); d != "" {
+				fmt.Printf("  Diverging from the bytes ETA: %s\n", d)
+			}
+		}
+	} else if s.HistoryRuns > 0 {
+		eta := c.out.displayETA(s.ETA)
+		fmt.Printf("  Decommissioning is starting: %s to move...\n", c.out.ibytes(uint64(s.InitialUsed)))
+		fmt.Printf("  ETA: %s (%s remaining, from cluster history: %s at %s)\n",
+			now.Add(eta).Format(time.RFC3339),
+			c.out.duration(eta),
+			plural(s.HistoryRuns, "past drain", "past drains"),
+			c.out.formatSpeed(s.Basis, s.HistorySpeed))
+	} else if s.HasPrior {
+		eta := c.out.displayETA(s.PriorETA)
+		fmt.Printf("  Decommissioning is starting: %s to move...\n", c.out.ibytes(uint64(s.InitialUsed)))
+		fmt.Printf("  ETA: %s (%s remaining, estimated from prior pool #%d at %s)\n",
+			now.Add(eta).Format(time.RFC3339),
+			c.out.duration(eta),
+			s.PriorPool,
+			c.out.formatSpeed(s.Basis, s.PriorSpeed))
+	} else if c.out.noETA {
+		fmt.Printf("  Decommissioning is starting: %s to move...\n", c.out.ibytes(uint64(s.InitialUsed)))
+	} else if s.InitialUsed > 0 {
+		// The amount to move is known from the first poll, so show
+		// the scale of the job even before there is any progress.
+		fmt.Printf("  Decommissioning is starting: %s to move, ETA not yet available...\n",
+			c.out.ibytes(uint64(s.InitialUsed)))
+	} else {
+		fmt.Println("  Decommissioning is starting, ETA not yet available...")
+	}
+
+	if c.out.costPerGB > 0 {
+		c.out.printCost(s)
+	}
+
+	if c.state != nil {
+		if prev, ok := c.state.Pools[stateKey(r.Alias, s.CmdLine)]; ok && !s.Restarted {
+			delta := s.CurrentSize - prev.CurrentSize
+			sign := "+"
+			if delta < 0 {
+				sign = "-"
+				delta = -delta
+			}
+			fmt.Printf("  Since last run: %s%s since %s\n", sign, c.out.ibytes(uint64(delta)), formatSince(prev.Time))
+		}
+	}
+}
+
+// printFinished prints the block of a pool whose drain ended since the
+// previous poll.
+func (c *consoleReporter) printFinished(s decomStatus) {
+	fmt.Printf("Pool #%d: decommission %s\n", s.ID+1, s.State)
+	fmt.Printf("  Moved: %s / %s (%s) in %s since %s\n",
+		c.out.ibytes(uint64(max(s.BytesFreed, 0))),
+		c.out.ibytes(uint64(s.InitialUsed)),
+		c.out.percent(100*float64(s.BytesFreed)/float64(max(s.InitialUsed, 1))),
+		c.out.duration(s.Elapsed),
+		s.StartTime.Format(time.RFC3339))
+	if s.ObjectsFailed > 0 {
+		fmt.Printf("  Failed: %s objects\n", c.out.comma(s.ObjectsFailed))
+	}
+}
+
+// printCost prints what moving the data left on a draining pool would
+// cost at -cost-per-gb, for tiers that charge for egress. GB is 10^9 bytes,
+// as providers bill it; the currency is whatever the rate is in.
+func (o outputOptions) printCost(s decomStatus) {
+	left := s.InitialUsed - max(s.BytesFreed, 0)
+	if left <= 0 {
+		return
+	}
+	fmt.Printf("  Transfer cost: %s to move the %s left, at %s per GB\n",
+		o.sprintf("%.2f", float64(left)/1e9*o.costPerGB),
+		o.ibytes(uint64(left)),
+		o.sprintf("%g", o.costPerGB))
+}
+
+// printRaw puts the physical data movement of a draining pool next to the
+// logical figures, to show why the drives move more than the objects' size.
+// The bytes moved are the server's count; before it has one, they are
+// estimated by scaling the bytes freed by the overhead the sets report.
+func (c *consoleReporter) printRaw(s decomStatus, sets []setUsage) {
+	if len(sets) == 0 {
+		return
+	}
+	overhead, ok := rawOverhead(sets)
+	moved := c.out.ibytes(uint64(s.BytesDone))
+	if s.BytesDone <= 0 {
+		if !ok {
+			return
+		}
+		moved = "~" + c.out.ibytes(uint64(float64(max(s.BytesFreed, 0))*overhead)) + " (estimated)"
+	}
+	line := fmt.Sprintf("  Raw data: %s moved", moved)
+	if ok {
+		var rawLeft uint64
+		for _, set := range sets {
+			rawLeft += set.RawUsage
+		}
+		line += fmt.Sprintf(", %s left on the drives (%sx the logical bytes, with erasure-coding parity)",
+			c.out.ibytes(rawLeft), c.out.sprintf("%.2f", overhead))
+	}
+	fmt.Println(line)
+}
+
+// printSets lists the raw usage of each erasure set of a draining pool.
+func (c *consoleReporter) printSets(sets []setUsage) {
+	for _, set := range sets {
+		fmt.Printf("    Set #%d: %s / %s raw used", set.Set,
+			c.out.ibytes(set.RawUsage), c.out.ibytes(set.RawCapacity))
+		if set.RawCapacity > 0 {
+			fmt.Printf(" (%s)", c.out.percent(100*float64(set.RawUsage)/float64(set.RawCapacity)))
+		}
+		if set.Usage > 0 {
+			fmt.Printf(", %s logical", c.out.ibytes(set.Usage))
+		}
+		fmt.Printf(", %s objects\n", c.out.comma(int64(set.Objects)))
+	}
+}
+
+// printDrives lists the drives most likely to gate a draining pool.
+func (c *consoleReporter) printDrives(drives []driveUsage) {
+	if len(drives) == 0 {
+		return
+	}
+	if drives[0].HasRate {
+		fmt.Println("    Slowest drives:")
+	} else {
+		fmt.Println("    Fullest drives:")
+	}
+	for _, d := range drives[:min(len(drives), shownDrives)] {
+		fmt.Printf("      %s: %s / %s used", d.Endpoint, c.out.ibytes(d.Used), c.out.ibytes(d.Total))
+		if d.HasRate {
+			fmt.Printf(", freeing %s", c.out.formatSpeed(basisBytes, d.Rate))
+		}
+		if d.State != "" && d.State != "ok" {
+			fmt.Printf(" (%s)", d.State)
+		}
+		fmt.Println()
+	}
+}
+
+func (c *consoleReporter) printPlan(p *planStatus, now time.Time) {
+	var order []string
+	for _, step := range p.Steps {
+		order = append(order, fmt.Sprintf("#%d", step.Pool))
+	}
+	fmt.Printf("Plan: %s\n", strings.Join(order, " -> "))
+
+	for _, step := range p.Steps {
+		switch {
+		case !step.Found:
+			fmt.Printf("  Pool #%d: not found on this cluster\n", step.Pool)
+		case step.ToMove <= 0:
+			fmt.Printf("  Pool #%d: %s, nothing left to move\n", step.Pool, step.State)
+		case step.Duration > 0:
+			fmt.Printf("  Pool #%d: %s, %s to move, ~%s\n", step.Pool, step.State,
+				c.out.ibytes(uint64(step.ToMove)), c.out.duration(c.out.displayETA(step.Duration)))
+		default:
+			fmt.Printf("  Pool #%d: %s, %s to move\n", step.Pool, step.State,
+				c.out.ibytes(uint64(step.ToMove)))
+		}
+	}
+
+	if p.Speed <= 0 {
+		fmt.Println("  Projected finish: not yet available, no planned pool is draining with measurable progress")
+		return
+	}
+	remaining := c.out.displayETA(p.Remaining)
+	fmt.Printf("  Projected finish: %s (%s from now at %s)\n",
+		now.Add(remaining).Format(time.RFC3339),
+		c.out.duration(remaining),
+		c.out.formatSpeed(basisBytes, p.Speed))
+}
+
+// delta renders a change in free space since the previous poll, e.g.
+// "+45 GiB", in green for progress and red for a regression with color.
+func (o outputOptions) delta(d int64) string {
+	sign, code := "+", "32"
+	if d < 0 {
+		sign, code, d = "-", "31", -d
+	}
+	s := o.pad(sign+o.ibytes(uint64(d)), o.bytesWidth()+1)
+	if o.color {
+		return "\033[" + code + "m" + s + "\033[0m"
+	}
+	return s
+}
+
+// durationWidth fits the longest formatDuration, "12d 23h 59m".
+const durationWidth = 11
+
+// bytesWidth fits the longest size formatIBytes and ibytes produce, such as
+// "1023.9 GiB".
+func (o outputOptions) bytesWidth() int {
+	if o.rawBytes {
+		return 24 // up to an EiB in bytes, with separators
+	}
+	return 9 + o.precision
+}
+
+// percentWidth fits "100.0%" at the -precision.
+func (o outputOptions) percentWidth() int {
+	if o.precision == 0 {
+		return 4
+	}
+	return 5 + o.precision
+}
+
+// pad right-aligns s to width with -fixed-width, so that a value keeps its
+// column from one poll to the next.
+func (o outputOptions) pad(s string, width int) string {
+	if !o.fixedWidth {
+		return s
+	}
+	if n := utf8.RuneCountInString(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
+// poolNumbers names 1-based pools, as in "pool #1" or "pools #1, #3".
+func poolNumbers(ns []int) string {
+	names := make([]string, len(ns))
+	for i, n := range ns {
+		names[i] = fmt.Sprintf("#%d", n)
+	}
+	if len(ns) == 1 {
+		return "pool " + names[0]
+	}
+	return "pools " + strings.Join(names, ", ")
+}
+
+// printPoolList shows every pool's capacity whether or not it is being
+// decommissioned.
+func (o outputOptions) printPoolList(pools []madmin.PoolStatus, listed int) {
+	shown, hidden := o.pageOf(len(pools))
+	for _, pool := range pools[shown.from:shown.to] {
+		fmt.Printf("Pool #%d: %s\n", pool.ID+1, o.poolLabel(pool.CmdLine))
+
+		d := pool.Decommission
+		if d == nil || d.TotalSize == 0 {
+			fmt.Println("  Size: not reported")
+		} else {
+			used := d.TotalSize - d.CurrentSize
+			fmt.Printf("  Size: %s used / %s total (%s), %s free\n",
+				o.ibytes(uint64(used)),
+				o.ibytes(uint64(d.TotalSize)),
+				o.percent(100*float64(used)/float64(d.TotalSize)),
+				o.ibytes(uint64(d.CurrentSize)))
+		}
+
+		state := "none"
+		if d != nil && !d.StartTime.IsZero() {
+			state = decomState(d)
+		}
+		if d != nil {
+			o.printTopology(pool.CmdLine, d.TotalSize, d.TotalSize-d.CurrentSize)
+		}
+		fmt.Printf("  Decommission: %s\n", state)
+		if state == stateComplete && d.CurrentSize < d.TotalSize {
+			// A clean drain leaves the pool empty; what's left may be
+			// objects that couldn't be moved.
+			fmt.Printf("  Warning: marked complete with %s still used", o.ibytes(uint64(d.TotalSize-d.CurrentSize)))
+			if d.ObjectsDecommissionFailed > 0 {
+				fmt.Printf(", %s objects (%s) failed to move",
+					o.comma(d.ObjectsDecommissionFailed), o.ibytes(uint64(d.BytesFailed)))
+			}
+			fmt.Println("; check the pool before removing it")
+		}
+		fmt.Println()
+	}
+
+	o.printHidden(hidden)
+	switch {
+	case listed == 0:
+		fmt.Println("The cluster reported no pools.")
+	case len(pools) == 0:
+		fmt.Printf("None of the %s reported by the cluster match the filters.\n", plural(listed, "pool", "pools"))
+	}
+}
+
+// printTopology describes the servers and drives of a pool from its command
+// line, with its sizes spread over the drives. used is the data left on the
+// pool; nothing is printed if the command line can't be parsed.
+func (o outputOptions) printTopology(cmdLine string, total, used int64) {
+	t, err := parseCmdLine(cmdLine)
+	if err != nil || t.Drives == 0 || len(t.Servers) == 0 {
+		return
+	}
+	fmt.Printf("  Topology: %s (%s), %s", plural(len(t.Servers), "server", "servers"), t.serverList(),
+		plural(t.Drives, "drive", "drives"))
+	if t.Drives%len(t.Servers) == 0 {
+		fmt.Printf(", %d per server", t.Drives/len(t.Servers))
+	}
+	fmt.Println()
+	if total > 0 {
+		fmt.Printf("  Per drive: %s capacity, %s used (average)\n",
+			o.ibytes(uint64(total/int64(t.Drives))), o.ibytes(uint64(max(used, 0)/int64(t.Drives))))
+	}
+}
+
+// poolLabel is how a pool is named in the output: its raw command line, or
+// a topology summary with -summarize-cmdline.
+func (o outputOptions) poolLabel(cmdLine string) string {
+	if !o.summarizeCmdLine {
+		return cmdLine
+	}
+	t, err := parseCmdLine(cmdLine)
+	if err != nil {
+		return cmdLine
+	}
+	return t.String()
+}
+
+// span is a half-open range of indices.
+type span struct{ from, to int }
+
+// pageOf applies -head or -tail to a list of n pools, returning the part to
+// show and how many are left out.
+func (o outputOptions) pageOf(n int) (span, int) {
+	switch {
+	case o.head > 0 && o.head < n:
+		return span{0, o.head}, n - o.head
+	case o.tail > 0 && o.tail < n:
+		return span{n - o.tail, n}, n - o.tail
+	}
+	return span{0, n}, 0
+}
+
+func (o outputOptions) printHidden(hidden int) {
+	if hidden == 0 {
+		return
+	}
+	flagName := "-head"
+	if o.tail > 0 {
+		flagName = "-tail"
+	}
+	fmt.Printf("(%s not shown because of %s)\n\n", plural(hidden, "more pool", "more pools"), flagName)
+}
+
+// displayETA applies -round-eta: to the minute under an hour, to 15 minutes
+// under a day and to the hour beyond, since more precision than that is
+// false precision for an extrapolation.
+func (o outputOptions) displayETA(d time.Duration) time.Duration {
+	if !o.roundETA {
+		return d
+	}
+	switch {
+	case d < time.Hour:
+		return d.Round(time.Minute)
+	case d < 24*time.Hour:
+		return d.Round(15 * time.Minute)
+	}
+	return d.Round(time.Hour)
+}
+
+// percent formats a percentage with -precision decimals.
+func (o outputOptions) percent(v float64) string {
+	return o.sprintf("%.*f%%", o.precision, v)
+}
+
+func (o outputOptions) formatSpeed(basis string, v float64) string {
+	// A slow drain shouldn't read as a stopped one: below the smallest
+	// figure the format can show, say so rather than print a 0.
+	if basis == basisObjects {
+		if least := math.Pow10(-o.precision); v > 0 && v < least {
+			return o.sprintf("< %.*f objects/sec", o.precision, least)
+		}
+		return o.sprintf("%.*f objects/sec", o.precision, v)
+	}
+	if v > 0 && v < 1 {
+		return "< 1 B/sec"
+	}
+	return o.formatIBytes(v) + "/sec"
+}
+
+var ibytesUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// formatIBytes is humanize.IBytes with -precision decimals, so speeds follow
+// it like the percentages do.
+func (o outputOptions) formatIBytes(v float64) string {
+	if o.rawBytes {
+		return strconv.FormatFloat(v, 'f', 0, 64) + " B"
+	}
+	i := 0
+	for v >= 1024 && i < len(ibytesUnits)-1 {
+		v /= 1024
+		i++
+	}
+	if i == 0 {
+		return o.sprintf("%.0f B", v)
+	}
+	return o.sprintf("%.*f %s", o.precision, v, ibytesUnits[i])
+}
+
+// Values of -time-style.
+const (
+	timeStyleHumanize = "humanize"
+	timeStylePrecise  = "precise"
+	timeStyleCompact  = "compact"
+)
+
+// duration renders a remaining time in the -time-style.
+func (o outputOptions) duration(d time.Duration) string {
+	switch o.timeStyle {
+	case timeStylePrecise:
+		return preciseDuration(d)
+	case timeStyleCompact:
+		return compactDuration(d)
+	}
+	return formatDuration(d)
+}
+
+// ago renders how long ago t was in the -time-style.
+func (o outputOptions) ago(t, now time.Time) string {
+	switch o.timeStyle {
+	case timeStylePrecise:
+		return preciseDuration(now.Sub(t)) + " ago"
+	case timeStyleCompact:
+		return compactDuration(now.Sub(t)) + " ago"
+	}
+	return humanize.RelTime(t, now, "ago", "from now")
+}
+
+// preciseDuration spells a duration out to the minute, e.g. "2 hours 15
+// minutes".
+func preciseDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	mins := int(d.Minutes()) % 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, plural(days, "day", "days"))
+	}
+	if hours > 0 {
+		parts = append(parts, plural(hours, "hour", "hours"))
+	}
+	if mins > 0 {
+		parts = append(parts, plural(mins, "minute", "minutes"))
+	}
+	if len(parts) == 0 {
+		return "less than a minute"
+	}
+	return strings.Join(parts, " ")
+}
+
+// compactDuration rounds a duration to its largest unit, e.g. "~2h".
+func compactDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("~%dd", int(d.Round(24*time.Hour)/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("~%dh", int(d.Round(time.Hour)/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("~%dm", int(d.Round(time.Minute)/time.Minute))
+	}
+	return "< 1m"
+}
+
+func formatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	mins := int(d.Minutes()) % 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if mins > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", mins))
+	}
+	if len(parts) == 0 {
+		return "< 1m"
+	}
+	return strings.Join(parts, " ")
+}
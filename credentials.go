@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// webIdentityTokenEnv names the file holding a web identity token (JWT) to
+// exchange for temporary credentials, as set for Kubernetes service
+// accounts; roleARNEnv optionally picks the role to assume.
+const (
+	webIdentityTokenEnv = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	roleARNEnv          = "AWS_ROLE_ARN"
+)
+
+// credentialSource is a link of the credentials chain, named for
+// -show-identity.
+type credentialSource struct {
+	credentials.Provider
+	name string
+}
+
+// credentialChain lists where credentials are looked for, in order; the
+// first source with keys is used:
+//
+//  1. -access-key, -secret-key and -session-token
+//  2. the alias, from MC_HOST_<alias> or the mc config
+//  3. MINIO_ROOT_USER/MINIO_ROOT_PASSWORD or MINIO_ACCESS_KEY/MINIO_SECRET_KEY
+//  4. STS: the token in AWS_WEB_IDENTITY_TOKEN_FILE exchanged with the
+//     cluster's AssumeRoleWithWebIdentity for temporary credentials, which
+//     are renewed before they expire
+//
+// The environment comes after the alias so that it can't silently override
+// the keys of the alias that was asked for. endpoint is the alias URL as
+// parseAliasURL normalized it, and client is used for the STS requests.
+func credentialChain(ac aliasConfig, endpoint *url.URL, opts clientOptions, client *http.Client) []credentialSource {
+	chain := []credentialSource{
+		{&credentials.Static{Value: credentials.Value{
+			AccessKeyID:     opts.accessKey,
+			SecretAccessKey: opts.secretKey,
+			SessionToken:    opts.sessionToken,
+			SignerType:      credentials.SignatureV4,
+		}}, "-access-key"},
+		{&credentials.Static{Value: credentials.Value{
+			AccessKeyID:     ac.AccessKey,
+			SecretAccessKey: ac.SecretKey,
+			SessionToken:    ac.SessionToken,
+			SignerType:      credentials.SignatureV4,
+		}}, "alias"},
+		{&credentials.EnvMinio{}, "MINIO_* environment"},
+	}
+	// Without a token file there is nothing to exchange, and asking the
+	// cluster would only fail.
+	if tokenFile := os.Getenv(webIdentityTokenEnv); tokenFile != "" {
+		chain = append(chain, credentialSource{&credentials.STSWebIdentity{
+			Client:      client,
+			STSEndpoint: endpoint.Scheme + "://" + endpoint.Host,
+			GetWebIDTokenExpiry: func() (*credentials.WebIdentityToken, error) {
+				token, err := os.ReadFile(tokenFile)
+				if err != nil {
+					return nil, err
+				}
+				return &credentials.WebIdentityToken{Token: string(token)}, nil
+			},
+			RoleARN: os.Getenv(roleARNEnv),
+		}, "STS web identity"})
+	}
+	return chain
+}
+
+// newChainCredentials wraps chain for madmin, which retrieves credentials
+// from the first source that has them and retrieves them again once they
+// expire.
+func newChainCredentials(chain []credentialSource) *credentials.Credentials {
+	providers := make([]credentials.Provider, len(chain))
+	for i, src := range chain {
+		providers[i] = src
+	}
+	return credentials.NewChainCredentials(providers)
+}
+
+// chainIdentity describes the credentials chain resolves to for
+// -show-identity: like aliasConfig.identity, but naming the source. An STS
+// source is asked for its credentials, so a failing exchange shows here.
+func chainIdentity(alias string, ac aliasConfig, chain []credentialSource) string {
+	for _, src := range chain {
+		v, err := src.Retrieve()
+		if err != nil {
+			if _, sts := src.Provider.(*credentials.STSWebIdentity); sts {
+				return fmt.Sprintf("Alias %s: %s, no credentials: %s: %v", alias, ac.URL, src.name, err)
+			}
+			continue
+		}
+		if v.AccessKeyID == "" && v.SecretAccessKey == "" {
+			continue
+		}
+		id := aliasConfig{URL: ac.URL, AccessKey: v.AccessKeyID, SecretKey: v.SecretAccessKey, SessionToken: v.SessionToken}.identity(alias)
+		return fmt.Sprintf("%s (from %s)", id, src.name)
+	}
+	return ac.identity(alias) + " (no credentials found)"
+}
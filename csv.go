@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// csvHeader names the columns of -csv rows, after the -jsonl fields.
+var csvHeader = []string{
+	"time", "alias", "pool", "cmdline", "state", "start_time", "elapsed_seconds",
+	"total_size", "initial_used", "bytes_freed", "used_now", "objects_done", "objects_failed", "basis",
+	"progress_percent", "speed", "eta_seconds", "recent_speed", "recent_eta_seconds",
+}
+
+// csvRow renders p in csvHeader order. Estimates not available yet are
+// empty.
+func csvRow(p jsonPool) []string {
+	opt := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	i := func(v int64) string { return strconv.FormatInt(v, 10) }
+	return []string{
+		p.Time.UTC().Format(time.RFC3339Nano), p.Alias, strconv.Itoa(p.ID), p.CmdLine, p.State,
+		p.StartTime.UTC().Format(time.RFC3339Nano), strconv.FormatFloat(p.ElapsedSeconds, 'f', -1, 64),
+		i(p.TotalSize), i(p.InitialUsed), i(p.BytesFreed), i(p.UsedNow), i(p.ObjectsDone), i(p.ObjectsFailed), p.Basis,
+		opt(p.ProgressPercent), opt(p.Speed), opt(p.ETASeconds), opt(p.RecentSpeed), opt(p.RecentETASeconds),
+	}
+}
+
+// appendCSV appends a row per pool to path, starting with the header when
+// the file is new or empty, so that one file can collect many runs.
+func appendCSV(path string, r *pollReport) error {
+	fh, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("output file: %w", err)
+	}
+	fi, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return fmt.Errorf("output file: %w", err)
+	}
+	w := csv.NewWriter(fh)
+	if fi.Size() == 0 {
+		w.Write(csvHeader)
+	}
+	for _, s := range r.changed() {
+		w.Write(csvRow(newJSONPool(r, s)))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fh.Close()
+		return fmt.Errorf("output file: write %s: %w", path, err)
+	}
+	return fh.Close()
+}
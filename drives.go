@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// shownDrives is how many of a draining pool's drives -verbose lists.
+const shownDrives = 3
+
+// driveUsage is the space used on one drive of a pool, and in watch mode how
+// fast it is being freed.
+type driveUsage struct {
+	Endpoint string
+	State    string
+	Used     uint64
+	Total    uint64
+	HasRate  bool
+	Rate     float64 // bytes/sec freed since the drive was first seen
+}
+
+// driveSample is the first observation of a drive.
+type driveSample struct {
+	Time time.Time
+	Used uint64
+}
+
+// driveTracker remembers the first observation of each drive, so that
+// successive polls give a per-drive drain rate.
+type driveTracker struct {
+	first map[string]driveSample
+}
+
+func newDriveTracker() *driveTracker {
+	return &driveTracker{first: map[string]driveSample{}}
+}
+
+// poolDrives returns the drives of every pool in info, keyed by pool ID.
+// Drives are ordered with the one gating the drain first: the slowest when
+// rates are known, otherwise the fullest.
+func (t *driveTracker) poolDrives(info madmin.InfoMessage, now time.Time) map[int][]driveUsage {
+	out := map[int][]driveUsage{}
+	for _, srv := range info.Servers {
+		for _, disk := range srv.Disks {
+			if disk.PoolIndex < 0 {
+				continue
+			}
+			d := driveUsage{Endpoint: disk.Endpoint, State: disk.State, Used: disk.UsedSpace, Total: disk.TotalSpace}
+			if first, ok := t.first[disk.Endpoint]; !ok {
+				t.first[disk.Endpoint] = driveSample{Time: now, Used: disk.UsedSpace}
+			} else if elapsed := now.Sub(first.Time).Seconds(); elapsed > 10 {
+				d.HasRate = true
+				d.Rate = (float64(first.Used) - float64(disk.UsedSpace)) / elapsed
+			}
+			out[disk.PoolIndex] = append(out[disk.PoolIndex], d)
+		}
+	}
+	for _, drives := range out {
+		sort.SliceStable(drives, func(i, j int) bool {
+			a, b := drives[i], drives[j]
+			if a.HasRate && b.HasRate {
+				return a.Rate < b.Rate
+			}
+			return a.Used > b.Used
+		})
+	}
+	return out
+}
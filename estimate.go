@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// printEstimate answers "how long would moving this much data take at this
+// speed" without a cluster, for planning a drain before starting it.
+func (o outputOptions) printEstimate(size, speed string) error {
+	bytes, err := humanize.ParseBytes(size)
+	if err != nil {
+		return fmt.Errorf("invalid -estimate-for: %w", err)
+	}
+	bps, err := humanize.ParseBytes(speed)
+	if err != nil {
+		return fmt.Errorf("invalid -at-speed: %w", err)
+	}
+	if bps == 0 {
+		return fmt.Errorf("invalid -at-speed %s: want more than 0", speed)
+	}
+
+	eta := o.displayETA(time.Duration(float64(bytes)/float64(bps)) * time.Second)
+	fmt.Printf("Moving %s at %s would take %s (finishing %s if started now)\n",
+		o.formatIBytes(float64(bytes)), o.formatSpeed(basisBytes, float64(bps)),
+		o.duration(eta), time.Now().Add(eta).Format(time.RFC3339))
+	return nil
+}
+
+// printReadings estimates speed and ETA from two readings of the data left
+// on a draining pool, as shown by "mc admin decommission status", taken over
+// apart. total, the data there was when the drain started, is optional and
+// adds the progress.
+func (o outputOptions) printReadings(from, to string, over time.Duration, total string) error {
+	before, err := humanize.ParseBytes(from)
+	if err != nil {
+		return fmt.Errorf("invalid -from-size: %w", err)
+	}
+	after, err := humanize.ParseBytes(to)
+	if err != nil {
+		return fmt.Errorf("invalid -to-size: %w", err)
+	}
+	if over <= 0 {
+		return fmt.Errorf("invalid -over %s: want the time between the readings", over)
+	}
+	if after >= before {
+		return fmt.Errorf("no progress between the readings: -to-size %s is not below -from-size %s", to, from)
+	}
+
+	moved := float64(before - after)
+	speed := moved / over.Seconds()
+	fmt.Printf("Speed: %s (%s moved in %s)\n", o.formatSpeed(basisBytes, speed), o.formatIBytes(moved), o.duration(over))
+	if total != "" {
+		initial, err := humanize.ParseBytes(total)
+		if err != nil {
+			return fmt.Errorf("invalid -total: %w", err)
+		}
+		if initial < before {
+			return fmt.Errorf("invalid -total %s: want at least -from-size", total)
+		}
+		fmt.Printf("Progress: %s / %s moved (%s)\n", o.formatIBytes(float64(initial-after)), o.formatIBytes(float64(initial)),
+			o.percent(100*float64(initial-after)/float64(initial)))
+	}
+	eta := o.displayETA(time.Duration(float64(after)/speed) * time.Second)
+	fmt.Printf("ETA: %s (%s remaining to move %s)\n", time.Now().Add(eta).Format(time.RFC3339), o.duration(eta), o.formatIBytes(float64(after)))
+	return nil
+}
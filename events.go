@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types published to the message queue.
+const (
+	eventState    = "state"
+	eventProgress = "progress"
+	eventETAAlert = "etaAlert"
+)
+
+// event is the payload published for decommission state changes and
+// periodic progress updates.
+type event struct {
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"`
+	RunID         string    `json:"runId"`
+	Alias         string    `json:"alias"`
+	Pool          int       `json:"pool"`
+	CmdLine       string    `json:"cmdline"`
+	State         string    `json:"state"`
+	PreviousState string    `json:"previousState,omitempty"`
+	InitialUsed   int64     `json:"initialUsed"`
+	BytesFreed    int64     `json:"bytesFreed"`
+	ObjectsDone   int64     `json:"objectsDone"`
+	Basis         string    `json:"basis"`
+	Progress      *float64  `json:"progress,omitempty"`
+	Speed         *float64  `json:"speed,omitempty"`
+	ETASeconds    *float64  `json:"etaSeconds,omitempty"`
+	LimitSeconds  *float64  `json:"limitSeconds,omitempty"` // etaAlert only
+}
+
+// eventPublisher turns computed statuses into events. State events go to
+// <subject>.state whenever a pool's state differs from the previous poll;
+// every active pool also gets a <subject>.progress event per poll.
+type eventPublisher struct {
+	conn      *natsConn
+	subject   string
+	lastState map[string]string // keyed by stateKey, as the monitors of a fleet share it
+}
+
+func newEventPublisher(natsURL, subject string) (*eventPublisher, error) {
+	conn, err := newNATSConn(natsURL)
+	if err != nil {
+		return nil, err
+	}
+	return &eventPublisher{
+		conn:      conn,
+		subject:   subject,
+		lastState: map[string]string{},
+	}, nil
+}
+
+func newEvent(typ string, r *pollReport, s decomStatus) event {
+	ev := event{
+		Type:        typ,
+		Time:        r.Time,
+		RunID:       r.RunID,
+		Alias:       r.Alias,
+		Pool:        s.ID + 1,
+		CmdLine:     s.CmdLine,
+		State:       s.State,
+		InitialUsed: s.InitialUsed,
+		BytesFreed:  s.BytesFreed,
+		ObjectsDone: s.ObjectsDone,
+		Basis:       s.Basis,
+	}
+	if s.HasProgress {
+		progress, speed := s.Progress, s.Speed
+		ev.Progress, ev.Speed = &progress, &speed
+	}
+	if s.HasETA {
+		eta := s.ETA.Seconds()
+		ev.ETASeconds = &eta
+	}
+	return ev
+}
+
+func (p *eventPublisher) send(subject string, ev event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return p.conn.publish(subject, data)
+}
+
+// report emits the events for one poll. It stops at the first failure so a
+// down broker costs one error per poll rather than one per pool.
+func (p *eventPublisher) report(r *pollReport) error {
+	for _, s := range r.Pools {
+		key := stateKey(r.Alias, s.CmdLine)
+		if prev, ok := p.lastState[key]; !ok || prev != s.State {
+			ev := newEvent(eventState, r, s)
+			ev.PreviousState = prev
+			if err := p.send(p.subject+".state", ev); err != nil {
+				return err
+			}
+			p.lastState[key] = s.State
+		}
+
+		if s.State == stateActive {
+			if err := p.send(p.subject+".progress", newEvent(eventProgress, r, s)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// fieldRow is a line of the -fields table.
+type fieldRow struct {
+	name, value string
+}
+
+// printFields prints every field of each pool's status as the admin API
+// returned it, in a two-column table per pool, under the JSON names that
+// -dump-raw shows. Sizes are also given humanized, unless -raw-bytes.
+func (o outputOptions) printFields(pools []madmin.PoolStatus) {
+	for i, pool := range pools {
+		if i > 0 {
+			fmt.Println()
+		}
+		rows := o.structRows("", reflect.ValueOf(pool))
+		width := 0
+		for _, r := range rows {
+			width = max(width, len(r.name))
+		}
+		fmt.Printf("Pool #%d:\n", pool.ID+1)
+		for _, r := range rows {
+			fmt.Printf("  %-*s  %s\n", width, r.name, r.value)
+		}
+	}
+}
+
+// structRows flattens the struct v into rows, naming the fields of nested
+// structs after their parent, as in decommissionInfo.startSize.
+func (o outputOptions) structRows(prefix string, v reflect.Value) []fieldRow {
+	var rows []fieldRow
+	for i := range v.NumField() {
+		f, fv := v.Type().Field(i), v.Field(i)
+		name := prefix + jsonName(f)
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				rows = append(rows, fieldRow{name, "null"})
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if t, ok := fv.Interface().(time.Time); ok {
+			value := t.Format(time.RFC3339Nano)
+			if t.IsZero() {
+				value += " (zero)"
+			}
+			rows = append(rows, fieldRow{name, value})
+			continue
+		}
+		if fv.Kind() == reflect.Struct {
+			rows = append(rows, o.structRows(name+".", fv)...)
+			continue
+		}
+		value := fmt.Sprint(fv.Interface())
+		// Go names say which numbers are sizes; the JSON ones don't always.
+		if !o.rawBytes && fv.Kind() == reflect.Int64 && fv.Int() >= 0 && (strings.Contains(f.Name, "Size") || strings.Contains(f.Name, "Bytes")) {
+			value += fmt.Sprintf(" (%s)", o.ibytes(uint64(fv.Int())))
+		}
+		rows = append(rows, fieldRow{name, value})
+	}
+	return rows
+}
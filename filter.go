@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// poolFilter selects which pools are reported. It is applied right after
+// fetching, so every output and aggregate sees the same set of pools.
+type poolFilter struct {
+	match   *regexp.Regexp // keep only pools whose CmdLine matches, if set
+	exclude *regexp.Regexp // drop pools whose CmdLine matches, if set
+	server  string         // keep only pools with this host among their endpoints, if set
+	// progressAbove and progressBelow, in percent, keep only decommissioned
+	// pools that are further along or less far along than that; 0 is off.
+	// They need the computed status, so they apply after the others.
+	progressAbove float64
+	progressBelow float64
+}
+
+func (f poolFilter) keep(pool madmin.PoolStatus) bool {
+	if f.match != nil && !f.match.MatchString(pool.CmdLine) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(pool.CmdLine) {
+		return false
+	}
+	if f.server != "" && !hasServer(pool.CmdLine, f.server) {
+		return false
+	}
+	return true
+}
+
+// hasServer reports whether the endpoints of cmdLine include host. A host
+// without a port matches that host on any port. Command lines that can't be
+// parsed never match.
+func hasServer(cmdLine, host string) bool {
+	t, err := parseCmdLine(cmdLine)
+	if err != nil {
+		return false
+	}
+	for _, s := range t.Servers {
+		if strings.EqualFold(s, host) {
+			return true
+		}
+		if name, _, err := net.SplitHostPort(s); err == nil && strings.EqualFold(name, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f poolFilter) apply(pools []madmin.PoolStatus) []madmin.PoolStatus {
+	var out []madmin.PoolStatus
+	for _, pool := range pools {
+		if f.keep(pool) {
+			out = append(out, pool)
+		}
+	}
+	return out
+}
+
+// keepStatus applies -progress-above and -progress-below. A drain that is
+// still warming up counts as 0%.
+func (f poolFilter) keepStatus(s decomStatus) bool {
+	var pct float64
+	if s.HasProgress {
+		pct = 100 * s.Progress
+	}
+	if f.progressAbove > 0 && pct <= f.progressAbove {
+		return false
+	}
+	if f.progressBelow > 0 && pct >= f.progressBelow {
+		return false
+	}
+	return true
+}
+
+// applyStatuses is apply for the progress filters.
+func (f poolFilter) applyStatuses(statuses []decomStatus) []decomStatus {
+	var out []decomStatus
+	for _, s := range statuses {
+		if f.keepStatus(s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
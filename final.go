@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// finalPool is how one of the pools a -wait-all watch waited for ended up.
+type finalPool struct {
+	Pool            int       `json:"pool"`
+	CmdLine         string    `json:"cmdline"`
+	State           string    `json:"state"`
+	StartTime       time.Time `json:"startTime"`
+	EndTime         time.Time `json:"endTime"` // first poll that saw it finished
+	DurationSeconds float64   `json:"durationSeconds"`
+	BytesMoved      int64     `json:"bytesMoved"`
+	ObjectsMoved    int64     `json:"objectsMoved"`
+}
+
+// finalReport is the summary -report-webhook POSTs once a -wait-all watch
+// is over, as a record of the whole migration rather than of one poll.
+type finalReport struct {
+	Type       string      `json:"type"` // always "final"
+	Alias      string      `json:"alias"`
+	WatchStart time.Time   `json:"watchStart"`
+	Time       time.Time   `json:"time"`
+	Complete   bool        `json:"complete"` // every pool completed
+	BytesMoved int64       `json:"bytesMoved"`
+	Pools      []finalPool `json:"pools"`
+}
+
+// finalTracker follows the pools a -wait-all watch waits for, noting when
+// each one stopped draining.
+type finalTracker struct {
+	start time.Time
+	pools map[string]*finalPool // keyed by CmdLine
+}
+
+func newFinalTracker(start time.Time) *finalTracker {
+	return &finalTracker{start: start, pools: map[string]*finalPool{}}
+}
+
+// update records the latest state of the waiting pools. A pool that is no
+// longer listed is taken as complete, as in waitDone.
+func (f *finalTracker) update(waiting map[string]int, statuses []decomStatus, now time.Time) {
+	for cmdLine, n := range waiting {
+		p, ok := f.pools[cmdLine]
+		if !ok {
+			p = &finalPool{Pool: n, CmdLine: cmdLine}
+			f.pools[cmdLine] = p
+		}
+		if !p.EndTime.IsZero() {
+			continue
+		}
+		listed := false
+		for _, s := range statuses {
+			if s.CmdLine != cmdLine {
+				continue
+			}
+			listed = true
+			p.State, p.StartTime = s.State, s.StartTime
+			p.BytesMoved, p.ObjectsMoved = s.BytesFreed, s.ObjectsDone
+		}
+		if !listed {
+			p.State = stateComplete
+		}
+		if p.State != stateActive {
+			p.EndTime = now
+			if !p.StartTime.IsZero() {
+				p.DurationSeconds = now.Sub(p.StartTime).Seconds()
+			}
+		}
+	}
+}
+
+func (f *finalTracker) report(alias string, now time.Time) finalReport {
+	r := finalReport{Type: "final", Alias: alias, WatchStart: f.start, Time: now, Complete: true}
+	for _, p := range f.pools {
+		r.Pools = append(r.Pools, *p)
+		r.BytesMoved += p.BytesMoved
+		if p.State != stateComplete {
+			r.Complete = false
+		}
+	}
+	sort.Slice(r.Pools, func(i, j int) bool { return r.Pools[i].Pool < r.Pools[j].Pool })
+	return r
+}
+
+// postFinalReport sends the summary to -report-webhook.
+func postFinalReport(url string, r finalReport) error {
+	return postJSON(&http.Client{Timeout: 10 * time.Second}, url, r)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// fleetFinish is when every pool draining on any of the polled clusters is
+// expected to be done: the latest of their ETAs.
+type fleetFinish struct {
+	At    time.Time
+	Alias string // of the pool that finishes last
+	Pool  int    // 1-based
+	// Draining counts the draining pools; Unknown names those with no ETA
+	// yet, which leave the fleet's finish unknown too.
+	Draining int
+	Unknown  []string
+}
+
+// newFleetFinish works out the fleet's finish from the latest poll of each
+// monitor.
+func newFleetFinish(monitors []*monitor) fleetFinish {
+	var f fleetFinish
+	for _, m := range monitors {
+		for _, s := range m.last {
+			if s.State != stateActive {
+				continue
+			}
+			f.Draining++
+			if !s.HasETA {
+				f.Unknown = append(f.Unknown, fmt.Sprintf("pool #%d on %s", s.ID+1, m.alias))
+				continue
+			}
+			// The poll's time is where the ETA counts from.
+			at := s.StartTime.Add(s.Elapsed).Add(s.ETA)
+			if at.After(f.At) {
+				f.At, f.Alias, f.Pool = at, m.alias, s.ID+1
+			}
+		}
+	}
+	return f
+}
+
+// printFleet prints the -fleet-eta line. failed names the clusters that
+// couldn't be polled, whose pools are missing from it.
+func (o outputOptions) printFleet(f fleetFinish, clusters int, failed []string, now time.Time) {
+	var line string
+	switch {
+	case f.Draining == 0:
+		line = fmt.Sprintf("Fleet: no pools draining on %s", plural(clusters, "cluster", "clusters"))
+	case len(f.Unknown) > 0:
+		line = fmt.Sprintf("Fleet: finish not known yet, no ETA for %s", strings.Join(f.Unknown, ", "))
+	default:
+		eta := o.displayETA(f.At.Sub(now))
+		line = fmt.Sprintf("Fleet: everything done by %s (%s remaining), last pool #%d on %s",
+			now.Add(eta).Format(time.RFC3339), o.duration(eta), f.Pool, f.Alias)
+	}
+	if len(failed) > 0 {
+		line += fmt.Sprintf(" (without %s, which couldn't be polled)", strings.Join(failed, ", "))
+	}
+	fmt.Println(line)
+}
+
+// pollFleet polls each cluster once, in the order given, and reports
+// whether they all succeeded. A cluster that fails is reported and skipped.
+// With several clusters, the text output of each is headed by its alias.
+func pollFleet(monitors []*monitor, fleetETA bool) bool {
+	var failed []string
+	for _, m := range monitors {
+		if len(monitors) > 1 && m.out.format == formatText && !m.out.quiet && !m.out.summaryOnly && m.out.glyphs == nil && !m.out.noBanner {
+			fmt.Printf("=== %s ===\n", m.alias)
+		}
+		if err := m.poll(); err != nil {
+			if len(monitors) > 1 {
+				err = fmt.Errorf("%s: %w", m.alias, err)
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed = append(failed, m.alias)
+		}
+	}
+	if fleetETA {
+		monitors[0].out.printFleet(newFleetFinish(monitors), len(monitors), failed, time.Now())
+	}
+	return len(failed) == 0
+}
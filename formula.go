@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// etaFormula is a user-supplied -eta-template: a text/template that renders
+// the remaining seconds of a drain from its raw figures.
+type etaFormula struct {
+	tmpl *template.Template
+}
+
+// etaInputs is what an -eta-template sees as dot. Sizes are in bytes, speeds
+// in the status's basis per second and times in seconds.
+type etaInputs struct {
+	Elapsed      float64
+	TotalSize    int64
+	InitialUsed  int64
+	BytesFreed   int64
+	UsedNow      int64
+	ObjectsDone  int64
+	TotalObjects int64
+	Basis        string
+	Progress     float64
+	Remaining    float64
+	Speed        float64
+	HasRecent    bool
+	RecentSpeed  float64
+	ETA          float64 // the built-in estimate
+}
+
+// formulaFuncs is the arithmetic available to -eta-template. Every function
+// takes ints or floats and returns a float.
+var formulaFuncs = template.FuncMap{
+	"add":  func(a, b any) (float64, error) { return apply2(a, b, func(x, y float64) float64 { return x + y }) },
+	"sub":  func(a, b any) (float64, error) { return apply2(a, b, func(x, y float64) float64 { return x - y }) },
+	"mul":  func(a, b any) (float64, error) { return apply2(a, b, func(x, y float64) float64 { return x * y }) },
+	"div":  func(a, b any) (float64, error) { return apply2(a, b, func(x, y float64) float64 { return x / y }) },
+	"min":  func(a, b any) (float64, error) { return apply2(a, b, math.Min) },
+	"max":  func(a, b any) (float64, error) { return apply2(a, b, math.Max) },
+	"pow":  func(a, b any) (float64, error) { return apply2(a, b, math.Pow) },
+	"sqrt": func(a any) (float64, error) { x, err := toFloat(a); return math.Sqrt(x), err },
+}
+
+func apply2(a, b any, f func(x, y float64) float64) (float64, error) {
+	x, err := toFloat(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := toFloat(b)
+	if err != nil {
+		return 0, err
+	}
+	return f(x, y), nil
+}
+
+func toFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("%v is not a number", v)
+}
+
+func parseETAFormula(text string) (*etaFormula, error) {
+	tmpl, err := template.New("eta").Funcs(formulaFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Catch misspelled fields now rather than on every poll. Executing the
+	// template only checks the branches taken, so every field is looked up;
+	// the result for all-zero inputs means nothing.
+	if err := checkFields(tmpl.Tree.Root); err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, etaInputs{}); err != nil {
+		return nil, err
+	}
+	return &etaFormula{tmpl: tmpl}, nil
+}
+
+// checkFields reports a field of dot (.Field or $.Field) anywhere in the
+// template that etaInputs doesn't have.
+func checkFields(node parse.Node) error {
+	inputs := reflect.TypeFor[etaInputs]()
+	check := func(n parse.Node, ident []string) error {
+		if len(ident) == 0 {
+			return nil
+		}
+		if _, ok := inputs.FieldByName(ident[0]); !ok {
+			return fmt.Errorf("%s: no such field", n)
+		}
+		return nil
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Nodes {
+			if err := checkFields(c); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return checkFields(n.Pipe)
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		return checkFields(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Cmds {
+			if err := checkFields(c); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := checkFields(arg); err != nil {
+				return err
+			}
+		}
+	case *parse.ChainNode:
+		return checkFields(n.Node)
+	case *parse.FieldNode:
+		return check(n, n.Ident)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			return check(n, n.Ident[1:])
+		}
+	}
+	return nil
+}
+
+func checkBranch(n *parse.BranchNode) error {
+	for _, c := range []parse.Node{n.Pipe, n.List, n.ElseList} {
+		if err := checkFields(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eval renders the template for s and parses the result as the remaining
+// time.
+func (f *etaFormula) eval(s decomStatus) (time.Duration, error) {
+	in := etaInputs{
+		Elapsed:      s.Elapsed.Seconds(),
+		TotalSize:    s.TotalSize,
+		InitialUsed:  s.InitialUsed,
+		BytesFreed:   s.BytesFreed,
+		UsedNow:      s.UsedNow,
+		ObjectsDone:  s.ObjectsDone,
+		TotalObjects: s.TotalObjects,
+		Basis:        s.Basis,
+		Progress:     s.Progress,
+		Remaining:    s.remaining(),
+		Speed:        s.Speed,
+		HasRecent:    s.HasRecent,
+		RecentSpeed:  s.RecentSpeed,
+		ETA:          s.ETA.Seconds(),
+	}
+	var b strings.Builder
+	if err := f.tmpl.Execute(&b, in); err != nil {
+		return 0, err
+	}
+	out := strings.TrimSpace(b.String())
+	secs, err := strconv.ParseFloat(out, 64)
+	if err != nil {
+		return 0, fmt.Errorf("result %q is not a number of seconds", out)
+	}
+	if math.IsNaN(secs) || math.IsInf(secs, 0) || secs < 0 {
+		return 0, fmt.Errorf("result %s is not a usable number of seconds", out)
+	}
+	return time.Duration(secs * float64(time.Second)).Round(time.Second), nil
+}
+
+// applyFormula replaces the ETA of a draining pool with the -eta-template's.
+// The recent and range estimates are left as they are.
+func (s *decomStatus) applyFormula(f *etaFormula) error {
+	if !s.HasProgress || s.State != stateActive || s.Progress >= 1 {
+		return nil
+	}
+	eta, err := f.eval(*s)
+	if err != nil {
+		return fmt.Errorf("-eta-template for pool %d: %w", s.ID+1, err)
+	}
+	s.HasETA, s.ETA = true, eta
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseETAFormula(t *testing.T) {
+	tests := []struct {
+		text    string
+		wantErr bool
+	}{
+		{"{{div .Remaining .Speed}}", false},
+		{"{{mul (div .Remaining .Speed) 1.2}}", false},
+		{"{{div .Remaining .Sped}}", true},
+		{"{{div .Remaining", true},
+		{"{{frobnicate .Remaining}}", true},
+		{"{{if .HasRecent}}{{div .Remaining .RecentSped}}{{else}}{{mul .ETA 1.2}}{{end}}", true},
+		{"{{if .HasRecent}}{{div .Remaining .RecentSpeed}}{{else}}{{mul .ETAA 1.2}}{{end}}", true},
+		{"{{if .HasRecent}}{{div .Remaining .RecentSpeed}}{{else}}{{mul .ETA 1.2}}{{end}}", false},
+		{"{{with .Speed}}{{div $.Remaining .}}{{end}}", false},
+		{"{{with .Speed}}{{div $.Remainder .}}{{end}}", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if _, err := parseETAFormula(tt.text); (err != nil) != tt.wantErr {
+				t.Errorf("parseETAFormula(%q) error = %v, want error %t", tt.text, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestETAFormulaEval(t *testing.T) {
+	s := draining(3600, 2, time.Hour)
+	tests := []struct {
+		text    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"{{div .Remaining .Speed}}", 30 * time.Minute, false},
+		{"{{mul .ETA 1.5}}", 90 * time.Minute, false},
+		{"{{max (div .Remaining .Speed) 7200}}", 2 * time.Hour, false},
+		{"{{sqrt 14400}}", 2 * time.Minute, false},
+		{"{{div .Remaining 0}}", 0, true},
+		{"{{sub 0 .Remaining}}", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			f, err := parseETAFormula(tt.text)
+			if err != nil {
+				t.Fatalf("parseETAFormula(%q): %v", tt.text, err)
+			}
+			got, err := f.eval(s)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("eval(%q) = %s, %v, want %s, error %t", tt.text, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
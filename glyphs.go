@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// nearDone is the progress from which a draining pool is drawn as nearly
+// done by -glyphs.
+const nearDone = 0.9
+
+// glyphSet is the character -glyphs draws for each pool state.
+type glyphSet struct {
+	spinner  []string // draining, one frame per poll
+	nearDone string
+	stalled  string
+	complete string
+	failed   string
+	canceled string
+}
+
+var (
+	unicodeGlyphs = glyphSet{
+		spinner:  []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+		nearDone: "⣿", stalled: "⚠", complete: "✓", failed: "✗", canceled: "⊘",
+	}
+	asciiGlyphs = glyphSet{
+		spinner:  []string{"|", "/", "-", `\`},
+		nearDone: "#", stalled: "!", complete: "+", failed: "x", canceled: "-",
+	}
+)
+
+// glyphSetFor is the glyphs for -glyphs, or nil when it isn't set.
+func glyphSetFor(enabled bool) *glyphSet {
+	if !enabled {
+		return nil
+	}
+	return terminalGlyphs()
+}
+
+// terminalGlyphs picks the Unicode glyphs when the locale's character set
+// is UTF-8, as with LANG=en_US.UTF-8, and the ASCII ones otherwise.
+func terminalGlyphs() *glyphSet {
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		// The first one set decides, as for the C library.
+		if v := os.Getenv(env); v != "" {
+			v = strings.ToLower(v)
+			if strings.Contains(v, "utf-8") || strings.Contains(v, "utf8") {
+				return &unicodeGlyphs
+			}
+			return &asciiGlyphs
+		}
+	}
+	return &asciiGlyphs
+}
+
+// glyph is the character for a pool's state. A draining pool that made no
+// progress over the recent window is stalled; one that is at least
+// nearDone through is drawn as such, and the others spin.
+func (g glyphSet) glyph(s decomStatus, frame int) string {
+	switch {
+	case s.State == stateComplete:
+		return g.complete
+	case s.State == stateFailed:
+		return g.failed
+	case s.State == stateCanceled:
+		return g.canceled
+	case s.HasRecent && s.RecentSpeed <= 0:
+		return g.stalled
+	case s.HasProgress && s.Progress >= nearDone:
+		return g.nearDone
+	}
+	return g.spinner[frame%len(g.spinner)]
+}
+
+// printGlyphs prints the -glyphs line of a cluster: its alias and a glyph
+// per decommissioned pool, in pool order.
+func (c *consoleReporter) printGlyphs(r *pollReport) {
+	if len(r.Pools) == 0 {
+		fmt.Printf("%s: no decommissions\n", r.Alias)
+		return
+	}
+	if c.frames == nil {
+		c.frames = map[string]int{}
+	}
+	frame := c.frames[r.Alias]
+	c.frames[r.Alias]++
+	var line strings.Builder
+	for _, s := range r.Pools {
+		line.WriteString(c.out.glyphs.glyph(s, frame))
+	}
+	fmt.Printf("%s: %s\n", r.Alias, line.String())
+}
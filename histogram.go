@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	histogramBuckets = 8
+	histogramWidth   = 30 // characters of the longest bar
+)
+
+// printHistograms writes the distribution of the interval speeds sampled
+// for each draining pool. A bimodal shape often points at contention.
+func (m *monitor) printHistograms(w io.Writer) {
+	if m.history == nil {
+		return
+	}
+	for _, s := range m.last {
+		if s.State != stateActive {
+			continue
+		}
+		speeds := s.intervalSpeeds(m.history.run(stateKey(m.alias, s.CmdLine), s.StartTime))
+		fmt.Fprintf(w, "Speed histogram, pool #%d (%s):\n", s.ID+1, plural(len(speeds), "interval", "intervals"))
+		if len(speeds) == 0 {
+			fmt.Fprintln(w, "  no intervals sampled yet")
+			continue
+		}
+		m.printHistogram(w, s.Basis, speeds)
+	}
+}
+
+func (m *monitor) printHistogram(w io.Writer, basis string, speeds []float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range speeds {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	buckets := histogramBuckets
+	if hi == lo {
+		buckets = 1
+	}
+	width := (hi - lo) / float64(buckets)
+
+	counts := make([]int, buckets)
+	peak := 0
+	for _, v := range speeds {
+		i := buckets - 1
+		if width > 0 {
+			i = min(int((v-lo)/width), buckets-1)
+		}
+		counts[i]++
+		peak = max(peak, counts[i])
+	}
+
+	labels := make([]string, buckets)
+	labelWidth := 0
+	for i := range counts {
+		from := lo + float64(i)*width
+		labels[i] = fmt.Sprintf("%s - %s", m.out.formatSpeed(basis, from), m.out.formatSpeed(basis, from+width))
+		labelWidth = max(labelWidth, len(labels[i]))
+	}
+	for i, n := range counts {
+		bar := strings.Repeat("#", (n*histogramWidth+peak-1)/peak)
+		fmt.Fprintf(w, "  %*s | %-*s %d\n", labelWidth, labels[i], histogramWidth, bar, n)
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// sample is one observation of a pool, stored as a line of JSON in the
+// history file.
+type sample struct {
+	Time    time.Time `json:"time"`
+	Alias   string    `json:"alias"`
+	Pool    int       `json:"pool"`
+	CmdLine string    `json:"cmdline"`
+	madmin.PoolDecommissionInfo
+
+	// Warmup marks one of the first samples of a watch, which are kept
+	// but not used for estimates. It is written to the file so that they
+	// stay left out when it is loaded again.
+	Warmup bool `json:"warmup,omitempty"`
+}
+
+// maxSamples is how many samples of a pool are kept in memory; a watch
+// left running for days thins out the older ones past that.
+const maxSamples = 10000
+
+// history is an append-only log of samples, kept in memory keyed by
+// alias+pool and mirrored to disk when it has a path.
+type history struct {
+	path    string
+	samples map[string][]sample
+	// warmup is how many samples per pool and run to leave out of the
+	// estimates after starting, as the first interval of a watch is often
+	// anomalous. recorded counts them.
+	warmup   int
+	recorded map[string]int
+}
+
+func loadHistory(path string) (*history, error) {
+	h := &history{path: path, samples: map[string][]sample{}, recorded: map[string]int{}}
+	if path == "" {
+		return h, nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var smp sample
+		if err := json.Unmarshal(sc.Bytes(), &smp); err != nil {
+			return nil, fmt.Errorf("parse %s:%d: %w", path, line, err)
+		}
+		key := stateKey(smp.Alias, smp.CmdLine)
+		h.samples[key] = append(h.samples[key], smp)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	// A long drain's file can hold far more than a watch keeps in memory.
+	for key := range h.samples {
+		for len(h.samples[key]) > maxSamples {
+			h.samples[key] = thin(h.samples[key])
+		}
+	}
+	return h, nil
+}
+
+// len is the number of samples held in memory.
+func (h *history) len() int {
+	n := 0
+	for _, samples := range h.samples {
+		n += len(samples)
+	}
+	return n
+}
+
+// record appends a sample for every draining pool. Finished pools are
+// recorded once, on the poll where they are first seen in a terminal state.
+func (h *history) record(alias string, pools []madmin.PoolStatus, now time.Time) error {
+	var added []sample
+	for _, pool := range pools {
+		d := pool.Decommission
+		if d == nil || d.StartTime.IsZero() {
+			continue
+		}
+		key := stateKey(alias, pool.CmdLine)
+		if decomState(d) != stateActive {
+			if prev := h.samples[key]; len(prev) > 0 {
+				last := prev[len(prev)-1].PoolDecommissionInfo
+				if last.StartTime.Equal(d.StartTime) && decomState(&last) == decomState(d) {
+					continue
+				}
+			}
+		}
+		smp := sample{
+			Time:                 now,
+			Alias:                alias,
+			Pool:                 pool.ID,
+			CmdLine:              pool.CmdLine,
+			PoolDecommissionInfo: *d,
+			Warmup:               h.recorded[key] < h.warmup,
+		}
+		h.recorded[key]++
+		h.samples[key] = append(h.samples[key], smp)
+		for len(h.samples[key]) > maxSamples {
+			h.samples[key] = thin(h.samples[key])
+		}
+		added = append(added, smp)
+	}
+	if len(added) == 0 || h.path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", h.path, err)
+	}
+	enc := json.NewEncoder(f)
+	for _, smp := range added {
+		if err := enc.Encode(smp); err != nil {
+			f.Close()
+			return fmt.Errorf("write %s: %w", h.path, err)
+		}
+	}
+	return f.Close()
+}
+
+// restarted reports whether the latest sample of key belongs to an earlier
+// decommission run than the one that began at start.
+func (h *history) restarted(key string, start time.Time) bool {
+	prev := h.samples[key]
+	return len(prev) > 0 && !prev[len(prev)-1].StartTime.Equal(start)
+}
+
+// reset forgets the in-memory samples of key so estimates start over. The
+// file keeps them, as the record of the earlier run.
+func (h *history) reset(key string) {
+	delete(h.samples, key)
+	delete(h.recorded, key)
+}
+
+// firstSince returns the earliest sample of the current decommission run
+// (identified by its start time) taken at or after t.
+func (h *history) firstSince(key string, start, t time.Time) (sample, bool) {
+	for _, smp := range h.samples[key] {
+		if smp.StartTime.Equal(start) && !smp.Warmup && !smp.Time.Before(t) {
+			return smp, true
+		}
+	}
+	return sample{}, false
+}
+
+// run returns the samples of the current decommission run of key, oldest
+// first, leaving out warm-up samples.
+func (h *history) run(key string, start time.Time) []sample {
+	var out []sample
+	for _, smp := range h.samples[key] {
+		if smp.StartTime.Equal(start) && !smp.Warmup {
+			out = append(out, smp)
+		}
+	}
+	return out
+}
+
+// peak is the most free space recorded for the run of key that started at
+// start, or 0 without samples.
+func (h *history) peak(key string, start time.Time) int64 {
+	var peak int64
+	for _, smp := range h.samples[key] {
+		if smp.StartTime.Equal(start) {
+			peak = max(peak, smp.CurrentSize)
+		}
+	}
+	return peak
+}
+
+// priorSpeed finds the pool of alias, other than except, whose drain most
+// recently completed, and returns its number (1-based) and average speed in
+// basis over the whole run.
+func (h *history) priorSpeed(alias, except, basis string) (int, float64, bool) {
+	var best sample
+	var speed float64
+	for key, samples := range h.samples {
+		if key == except || len(samples) == 0 {
+			continue
+		}
+		last := samples[len(samples)-1]
+		if last.Alias != alias || decomState(&last.PoolDecommissionInfo) != stateComplete || !last.Time.After(best.Time) {
+			continue
+		}
+		elapsed := last.Time.Sub(last.StartTime).Seconds()
+		done := float64(last.CurrentSize - last.StartSize)
+		if basis == basisObjects {
+			done = float64(last.ObjectsDecommissioned)
+		}
+		if elapsed <= 10 || done <= 0 {
+			continue
+		}
+		best, speed = last, done/elapsed
+	}
+	if speed == 0 {
+		return 0, 0, false
+	}
+	return best.Pool + 1, speed, true
+}
+
+// minHistoryRuns is how many completed drains it takes for clusterSpeed to
+// call their average typical of the cluster.
+const minHistoryRuns = 2
+
+// clusterSpeed is the typical drain speed of alias in basis: everything the
+// completed runs in the history moved, over the time they took. It returns
+// the number of runs behind it, and false with fewer than minHistoryRuns.
+func (h *history) clusterSpeed(alias, basis string) (int, float64, bool) {
+	var runs int
+	var done, elapsed float64
+	for _, samples := range h.samples {
+		for _, smp := range samples {
+			if smp.Alias != alias || decomState(&smp.PoolDecommissionInfo) != stateComplete {
+				continue
+			}
+			// A completed run is recorded once, so each sample here is
+			// a run of its own.
+			d := float64(smp.CurrentSize - smp.StartSize)
+			if basis == basisObjects {
+				d = float64(smp.ObjectsDecommissioned)
+			}
+			e := smp.Time.Sub(smp.StartTime).Seconds()
+			if e <= 10 || d <= 0 {
+				continue
+			}
+			runs++
+			done += d
+			elapsed += e
+		}
+	}
+	if runs < minHistoryRuns {
+		return runs, 0, false
+	}
+	return runs, done / elapsed, true
+}
+
+// lastPollAt is the time of the latest poll of alias in the history taken
+// at or before t.
+func (h *history) lastPollAt(alias string, t time.Time) (time.Time, bool) {
+	var last time.Time
+	for _, samples := range h.samples {
+		for _, smp := range samples {
+			if smp.Alias == alias && !smp.Time.After(t) && smp.Time.After(last) {
+				last = smp.Time
+			}
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// truncate forgets the samples taken after t, and stops mirroring to disk,
+// so that the history reads as it was at t.
+func (h *history) truncate(t time.Time) {
+	for key, samples := range h.samples {
+		var kept []sample
+		for _, smp := range samples {
+			if !smp.Time.After(t) {
+				kept = append(kept, smp)
+			}
+		}
+		h.samples[key] = kept
+	}
+	h.path = ""
+}
+
+// thin drops every other sample but the first and the last, halving the
+// samples while keeping the span they cover, so that estimates from the
+// start of the run and from recent progress still have their base. The
+// file keeps them all.
+func thin(samples []sample) []sample {
+	kept := make([]sample, 0, len(samples)/2+2)
+	for i, smp := range samples {
+		if i%2 == 0 || i == len(samples)-1 {
+			kept = append(kept, smp)
+		}
+	}
+	return kept
+}
+
+// snapshot rebuilds the pool listing of alias from the latest sample of
+// each pool, in pool order, for -at-time. Only pools that have been
+// decommissioned are in the history, so only they are listed. at is the
+// poll replayed: every draining pool has a sample of each poll, so one
+// whose last sample is older wasn't seen draining then, and is left out.
+// A finished drain is only recorded once, and stays listed.
+func (h *history) snapshot(alias string, at time.Time) []madmin.PoolStatus {
+	var pools []madmin.PoolStatus
+	for _, samples := range h.samples {
+		if len(samples) == 0 || samples[0].Alias != alias {
+			continue
+		}
+		last := samples[len(samples)-1]
+		info := last.PoolDecommissionInfo
+		if decomState(&info) == stateActive && last.Time.Before(at) {
+			continue
+		}
+		pools = append(pools, madmin.PoolStatus{ID: last.Pool, CmdLine: last.CmdLine, LastUpdate: last.Time, Decommission: &info})
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].ID < pools[j].ID })
+	return pools
+}
+
+// parseAtTime parses -at-time: an RFC 3339 timestamp, a duration ago, or a
+// clock time such as 03:00, meaning its latest occurrence in local time.
+func parseAtTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("15:04", s, time.Local); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+		if at.After(now) {
+			at = at.AddDate(0, 0, -1)
+		}
+		return at, nil
+	}
+	if t, err := parseSince(s, now); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -at-time %q: want an RFC 3339 timestamp, a clock time (15:04) or a duration ago", s)
+}
+
+// parseSince accepts either an RFC 3339 timestamp or a duration meaning
+// "this long ago".
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -since %q: want an RFC 3339 timestamp or a duration ago", s)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2026-02-16T03:00:00Z", time.Date(2026, 2, 16, 3, 0, 0, 0, time.UTC), false},
+		{"6h", now.Add(-6 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"0s", now, false},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseSince(tt.in, now)
+			if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %s, %v, want %s, error %t", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseAtTime(t *testing.T) {
+	now := time.Date(2026, 2, 16, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"03:00", time.Date(2026, 2, 16, 3, 0, 0, 0, time.Local), false},
+		{"12:00", now, false},
+		{"15:30", time.Date(2026, 2, 15, 15, 30, 0, 0, time.Local), false},
+		{"2026-02-10T08:00:00Z", time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC), false},
+		{"6h", now.Add(-6 * time.Hour), false},
+		{"25:00", time.Time{}, true},
+		{"noon", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAtTime(tt.in, now)
+			if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+				t.Errorf("parseAtTime(%q) = %s, %v, want %s, error %t", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestThin(t *testing.T) {
+	for _, n := range []int{2, 3, 10, 11} {
+		samples := make([]sample, n)
+		for i := range samples {
+			samples[i].Time = time.Unix(int64(i), 0)
+		}
+		got := thin(samples)
+		if want := (n+1)/2 + (n+1)%2; len(got) != want {
+			t.Errorf("thin(%d samples) kept %d, want %d", n, len(got), want)
+		}
+		if !got[0].Time.Equal(samples[0].Time) || !got[len(got)-1].Time.Equal(samples[n-1].Time) {
+			t.Errorf("thin(%d samples) kept %s to %s, want the first and last", n, got[0].Time, got[len(got)-1].Time)
+		}
+	}
+}
+
+func TestHistoryWarmupReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.warmup = 1
+	start := time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		d := madmin.PoolDecommissionInfo{StartTime: start, TotalSize: 1000, StartSize: 400, CurrentSize: 400 + int64(i)*100}
+		pools := []madmin.PoolStatus{{ID: 0, CmdLine: "pool", Decommission: &d}}
+		if err := h.record("test", pools, start.Add(time.Duration(i+1)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := stateKey("test", "pool")
+	if got, want := len(reloaded.run(key, start)), len(h.run(key, start)); got != want || got != 2 {
+		t.Errorf("reloaded run has %d samples, in memory %d, want 2 without the warm-up one", got, want)
+	}
+}
+
+func TestLoadHistoryBounded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC)
+	enc := json.NewEncoder(f)
+	const n = 5*maxSamples + 7
+	for i := range n {
+		smp := sample{Time: start.Add(time.Duration(i) * time.Second), Alias: "test", CmdLine: "pool",
+			PoolDecommissionInfo: madmin.PoolDecommissionInfo{StartTime: start, TotalSize: 1000}}
+		if err := enc.Encode(smp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.len(); got > maxSamples || got < maxSamples/2 {
+		t.Errorf("loaded %d of %d samples, want at most %d and at least half that", got, n, maxSamples)
+	}
+	samples := h.samples[stateKey("test", "pool")]
+	if first, last := samples[0].Time, samples[len(samples)-1].Time; !first.Equal(start) || !last.Equal(start.Add((n-1)*time.Second)) {
+		t.Errorf("loaded samples span %s to %s, want the file's whole span", first, last)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	h, err := loadHistory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC)
+	poll := func(at time.Duration, pools ...madmin.PoolStatus) {
+		if err := h.record("test", pools, start.Add(at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool := func(id int, current int64, complete bool) madmin.PoolStatus {
+		return madmin.PoolStatus{ID: id, CmdLine: string(rune('a' + id)), Decommission: &madmin.PoolDecommissionInfo{
+			StartTime: start, TotalSize: 1000, StartSize: 400, CurrentSize: current, Complete: complete}}
+	}
+	// Pool 0 finishes early, pool 1 drops out of the polls, pool 2 drains
+	// throughout.
+	poll(time.Minute, pool(0, 500, false), pool(1, 500, false), pool(2, 500, false))
+	poll(2*time.Minute, pool(0, 1000, true), pool(2, 600, false))
+	poll(3*time.Minute, pool(0, 1000, true), pool(2, 700, false))
+
+	var got []int
+	for _, p := range h.snapshot("test", start.Add(3*time.Minute)) {
+		got = append(got, p.ID)
+	}
+	if len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Errorf("snapshot lists pools %v, want [0 2]: the finished pool and the one still polled", got)
+	}
+}
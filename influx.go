@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// influxMeasurement is the measurement name of the -influx lines.
+const influxMeasurement = "decom"
+
+// influxTagEscaper escapes tag keys and values for the line protocol.
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// influxLine renders one draining pool as an InfluxDB line protocol point.
+// Estimates that aren't available yet are left out, as the protocol has no
+// nulls.
+func influxLine(alias string, now time.Time, s decomStatus) string {
+	tags := []string{
+		"cluster=" + influxTagEscaper.Replace(alias),
+		"pool=" + strconv.Itoa(s.ID+1),
+		"state=" + s.State,
+		"basis=" + s.Basis,
+	}
+	fields := []string{
+		fmt.Sprintf("total_size=%di", s.TotalSize),
+		fmt.Sprintf("initial_used=%di", s.InitialUsed),
+		fmt.Sprintf("bytes_freed=%di", s.BytesFreed),
+		fmt.Sprintf("used_now=%di", s.UsedNow),
+		fmt.Sprintf("objects_done=%di", s.ObjectsDone),
+		fmt.Sprintf("objects_failed=%di", s.ObjectsFailed),
+		fmt.Sprintf("elapsed_seconds=%g", s.Elapsed.Seconds()),
+	}
+	if s.HasProgress {
+		fields = append(fields,
+			fmt.Sprintf("progress=%g", s.Progress*100),
+			fmt.Sprintf("speed=%g", s.Speed))
+	}
+	if s.HasETA {
+		fields = append(fields, fmt.Sprintf("eta_seconds=%g", s.ETA.Seconds()))
+	}
+	if s.HasRecent {
+		fields = append(fields,
+			fmt.Sprintf("recent_speed=%g", s.RecentSpeed),
+			fmt.Sprintf("recent_eta_seconds=%g", s.RecentETA.Seconds()))
+	}
+	return fmt.Sprintf("%s,%s %s %d", influxMeasurement,
+		strings.Join(tags, ","), strings.Join(fields, ","), now.UnixNano())
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// sprintf formats numbers with the -locale's separators, or as fmt does
+// when no locale was given.
+func (o outputOptions) sprintf(format string, a ...any) string {
+	if o.printer == nil {
+		return fmt.Sprintf(format, a...)
+	}
+	return o.printer.Sprintf(format, a...)
+}
+
+// comma is humanize.Comma with the -locale's thousands separator.
+func (o outputOptions) comma(v int64) string {
+	if o.printer == nil {
+		return humanize.Comma(v)
+	}
+	return o.printer.Sprintf("%d", v)
+}
+
+// ibytes is humanize.IBytes with the -locale's decimal mark, or the exact
+// count with -raw-bytes.
+func (o outputOptions) ibytes(v uint64) string {
+	if o.rawBytes {
+		return strconv.FormatUint(v, 10) + " B"
+	}
+	if o.printer == nil {
+		return humanize.IBytes(v)
+	}
+	if v < 10 {
+		return o.printer.Sprintf("%d B", v)
+	}
+	// Same rounding as humanize: one decimal below 10, none above.
+	e := math.Floor(math.Log(float64(v)) / math.Log(1024))
+	val := math.Floor(float64(v)/math.Pow(1024, e)*10+0.5) / 10
+	format := "%.0f %s"
+	if val < 10 {
+		format = "%.1f %s"
+	}
+	return o.printer.Sprintf(format, val, ibytesUnits[int(e)])
+}
+
+func newPrinter(tag string) (*message.Printer, error) {
+	lang, err := language.Parse(tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -locale %q: %w", tag, err)
+	}
+	return message.NewPrinter(lang), nil
+}
@@ -1,15 +1,14 @@
 package main
 
 import (
-	"context"
 	"crypto/tls"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -17,191 +16,889 @@ import (
 	"github.com/minio/madmin-go/v3"
 )
 
-type aliasConfig struct {
-	URL       string `json:"url"`
-	AccessKey string `json:"accessKey"`
-	SecretKey string `json:"secretKey"`
-	API       string `json:"api"`
-	Path      string `json:"path"`
+// clientOptions are connection settings that come from flags rather than
+// the mc alias.
+type clientOptions struct {
+	clientCert string
+	clientKey  string
+	headers    http.Header // added to every admin request
+	// accessKey, secretKey and sessionToken take precedence over every
+	// other credentials source.
+	accessKey    string
+	secretKey    string
+	sessionToken string
 }
 
-type mcConfig struct {
-	Version string                 `json:"version"`
-	Aliases map[string]aliasConfig `json:"aliases"`
+// headerTransport adds fixed headers to each request, for gateways and
+// proxies in front of MinIO that require them. They are set after madmin
+// has signed the request; headers outside the signature don't invalidate it.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
 }
 
-func loadAlias(alias, configDir string) (aliasConfig, error) {
-	if configDir == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return aliasConfig{}, fmt.Errorf("get home dir: %w", err)
-		}
-		configDir = filepath.Join(homeDir, ".mc")
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
 	}
+	return t.base.RoundTrip(req)
+}
 
-	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
-	if err != nil {
-		return aliasConfig{}, fmt.Errorf("read %s: %w", configDir, err)
+// parseHeader parses a -header value of the form "Key: Value".
+func parseHeader(s string) (string, string, error) {
+	k, v, ok := strings.Cut(s, ":")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" || strings.ContainsAny(k, " \t") {
+		return "", "", fmt.Errorf("invalid -header %q: want \"Key: Value\"", s)
 	}
+	return k, strings.TrimSpace(v), nil
+}
 
-	var cfg mcConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return aliasConfig{}, fmt.Errorf("parse config: %w", err)
+// parseAliasURL parses an alias URL, defaulting to https when the scheme is
+// missing: url.Parse reads "myhost:9000" as scheme "myhost" with no host,
+// which would otherwise only fail later as a confusing connection error.
+func parseAliasURL(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
 	}
-
-	ac, ok := cfg.Aliases[alias]
-	if !ok {
-		return aliasConfig{}, fmt.Errorf("alias %q not found in %s", alias, configDir)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse URL %q: %w", raw, err)
+	}
+	if !strings.EqualFold(u.Scheme, "http") && !strings.EqualFold(u.Scheme, "https") {
+		return nil, fmt.Errorf("URL %q: unsupported scheme %q (want http or https)", raw, u.Scheme)
 	}
-	return ac, nil
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("URL %q has no host", raw)
+	}
+	return u, nil
 }
 
-func newAdminClient(ac aliasConfig) (*madmin.AdminClient, error) {
-	u, err := url.Parse(ac.URL)
+func newAdminClient(ac aliasConfig, opts clientOptions) (*madmin.AdminClient, error) {
+	u, err := parseAliasURL(ac.URL)
 	if err != nil {
-		return nil, fmt.Errorf("parse URL %q: %w", ac.URL, err)
+		return nil, err
 	}
-
-	secure := strings.EqualFold(u.Scheme, "https")
-	client, err := madmin.New(u.Host, ac.AccessKey, ac.SecretKey, secure)
+	transport, err := newTransport(ac, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	if secure {
-		client.SetCustomTransport(&http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		})
+	chain := credentialChain(ac, u, opts, &http.Client{Transport: transport})
+	client, err := madmin.NewWithOptions(u.Host, &madmin.Options{
+		Creds:  newChainCredentials(chain),
+		Secure: strings.EqualFold(u.Scheme, "https"),
+	})
+	if err != nil {
+		return nil, err
 	}
+	client.SetCustomTransport(transport)
 
 	return client, nil
 }
 
-func printStatus(client *madmin.AdminClient) {
-	ctx := context.Background()
-	pools, err := client.ListPoolsStatus(ctx)
+// newTransport is the transport for requests to the alias's cluster, admin
+// and STS alike.
+func newTransport(ac aliasConfig, opts clientOptions) (http.RoundTripper, error) {
+	u, err := parseAliasURL(ac.URL)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error listing pool status: %v\n", err)
-		return
+		return nil, err
+	}
+	if (opts.clientCert == "") != (opts.clientKey == "") {
+		return nil, errors.New("-client-cert and -client-key must be used together")
 	}
 
-	hasDraining := false
-	for _, pool := range pools {
-		d := pool.Decommission
-		if d == nil || d.StartTime.IsZero() {
-			continue
+	secure := strings.EqualFold(u.Scheme, "https")
+	transport := madmin.DefaultTransport(secure)
+	if secure {
+		tlsConfig := &tls.Config{InsecureSkipVerify: true}
+		if opts.clientCert != "" {
+			cert, err := tls.LoadX509KeyPair(opts.clientCert, opts.clientKey)
+			if err != nil {
+				return nil, fmt.Errorf("load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
 		}
-
-		if d.Complete || d.Failed || d.Canceled {
-			continue
+		transport = &http.Transport{
+			TLSClientConfig: tlsConfig,
 		}
+	} else if opts.clientCert != "" {
+		return nil, fmt.Errorf("client certificates require an https URL, alias has %q", ac.URL)
+	}
+	if len(opts.headers) > 0 {
+		transport = &headerTransport{base: transport, headers: opts.headers}
+	}
+	return transport, nil
+}
 
-		hasDraining = true
-
-		// StartSize/CurrentSize = free bytes at decom start / now.
-		// As data moves off pool, free space increases: CurrentSize > StartSize.
-		// initialUsed = data that needs to move off.
-		// bytesFreed = free space gained so far.
-		initialUsed := d.TotalSize - d.StartSize
-		bytesFreed := d.CurrentSize - d.StartSize
-		usedNow := d.TotalSize - d.CurrentSize
+// identity describes the credentials newAdminClient would sign with, for
+// -show-identity.
+func identity(alias string, ac aliasConfig, opts clientOptions) string {
+	u, err := parseAliasURL(ac.URL)
+	if err != nil {
+		return ac.identity(alias)
+	}
+	transport, err := newTransport(ac, opts)
+	if err != nil {
+		return ac.identity(alias)
+	}
+	return chainIdentity(alias, ac, credentialChain(ac, u, opts, &http.Client{Transport: transport}))
+}
 
-		fmt.Printf("Pool #%d: %s\n", pool.ID+1, pool.CmdLine)
+func main() {
+	if len(os.Args) > 1 && (os.Args[1] == simulateFlag || os.Args[1] == "-"+simulateFlag) {
+		if err := runSimulate(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-		elapsed := time.Since(d.StartTime)
-		fmt.Printf("  Started: %s (%s ago)\n", d.StartTime.Format(time.RFC3339), humanize.RelTime(d.StartTime, time.Now(), "", ""))
+	configDir := flag.String("config-dir", "", "path to mc config directory (default: $XDG_CONFIG_HOME/mc if it has a config.json, else ~/.mc)")
+	var configFiles stringList
+	flag.Var(&configFiles, "config-file", "mc config file to read instead of <config-dir>/config.json; repeat to merge, later files override")
+	var headers stringList
+	flag.Var(&headers, "header", "add this \"Key: Value\" header to every admin request; repeatable")
+	follow := flag.Bool("follow", false, "watch, appending a line per pool whenever its progress changes instead of redrawing the screen")
+	watch := flag.Bool("watch", false, "continuously monitor decommission status (every -interval)")
+	interval := flag.Duration("interval", defaultPollInterval, "time between polls in watch mode (at least 1s)")
+	diffSince := flag.Bool("diff-since", false, "show progress made since the previous invocation")
+	stateFilePath := flag.String("state-file", "", "path to the -diff-since state file (default: <user cache dir>/decom-eta/state.json)")
+	maxRetryDelay := flag.Duration("max-retry-delay", 5*time.Minute, "in watch mode, back off exponentially between consecutive failed polls up to this delay (-interval: no backoff)")
+	maxErrors := flag.Int("max-errors", 0, "in watch mode, exit after this many consecutive poll failures (0: never)")
+	natsURL := flag.String("nats-url", "", "publish decommission events to this NATS server (nats://[user:pass@]host:port)")
+	natsSubject := flag.String("nats-subject", "decom-eta", "subject prefix for NATS events (<prefix>.state, <prefix>.progress)")
+	comparePrior := flag.Bool("compare-to-previous-pool", false, "until a new drain has an ETA of its own, estimate one from the speed of the last pool that completed (needs -history-file or -watch)")
+	historyFile := flag.String("history-file", "", "append every poll's samples to this file (JSON lines) and use them for windowed estimates")
+	historyPrior := flag.Bool("history-prior", false, "until a drain's own estimate settles, base its ETA on the average speed of the cluster's drains completed in -history-file")
+	atTime := flag.String("at-time", "", "print the status as of this time (RFC 3339, 15:04 or a duration ago) from -history-file instead of asking the cluster")
+	since := flag.String("since", "", "compute speed and ETA only from progress after this time (RFC 3339 or a duration ago); requires -history-file")
+	plain := flag.Bool("plain", false, "append-only plain text output: no ANSI escapes or screen clears (for log capture)")
+	summarizeCmdLine := flag.Bool("summarize-cmdline", false, "show each pool as a server/drive count instead of its full command line")
+	dumpRawPath := flag.String("dump-raw", "", "write the raw ListPoolsStatus response as JSON to this file (\"-\" for stdout)")
+	fields := flag.Bool("fields", false, "print every field of each pool's raw status as a table instead of the computed status, for debugging")
+	clientCert := flag.String("client-cert", "", "TLS client certificate (PEM) for clusters that require mutual TLS")
+	clientKey := flag.String("client-key", "", "private key (PEM) for -client-cert")
+	accessKey := flag.String("access-key", "", "access key to use instead of the alias's, ahead of every other credentials source")
+	secretKey := flag.String("secret-key", "", "secret key for -access-key")
+	sessionToken := flag.String("session-token", "", "session token for temporary -access-key credentials")
+	etaBasis := flag.String("eta-basis", basisBytes, "measure progress and ETA in \"bytes\", \"objects\" or \"both\" side by side (the last two need -total-objects)")
+	totalObjects := flag.Int64("total-objects", 0, "number of objects in the draining pool, for -eta-basis objects")
+	quiet := flag.Bool("quiet", false, "suppress the status output (errors are still reported); for use with sinks such as -nats-url")
+	heartbeat := flag.Duration("heartbeat", 0, "with -watch -quiet or -only-changes, print a line confirming the watcher is alive this often (e.g. 1h)")
+	list := flag.Bool("list", false, "list every pool with its capacity, whether or not it is being decommissioned")
+	jsonOut := flag.Bool("json", false, "print each poll as a JSON document instead of text")
+	jsonlOut := flag.Bool("jsonl", false, "print one JSON object per draining pool per line instead of text")
+	eventLog := flag.String("event-log", "", "in watch mode, append a JSON line for each observed transition (started, milestones, stalled, completed, ...) to this file, \"-\" for stdout")
+	teeJSON := flag.String("tee-json", "", "also append the -json document of every poll to this file, one per line, whatever the console shows")
+	outputFile := flag.String("output-file", "", "also append one JSON line per draining pool per poll to this file")
+	csvOut := flag.Bool("csv", false, "write -output-file as CSV, with a header row only when the file is new or empty")
+	sqlitePath := flag.String("sqlite", "", "also insert a row per draining pool per poll into this SQLite database (created if missing)")
+	webhook := flag.String("webhook", "", "also POST each poll's JSON report to this URL")
+	metricsAddr := flag.String("metrics-addr", "", "with -watch, serve Prometheus metrics and a /healthz endpoint on this address (e.g. :9101)")
+	precision := flag.Int("precision", 1, "decimal places in percentages and speeds")
+	match := flag.String("match", "", "only report pools whose command line matches this regular expression")
+	progressAbove := flag.Float64("progress-above", 0, "report only decommissioned pools more than this percent done")
+	progressBelow := flag.Float64("progress-below", 0, "report only decommissioned pools less than this percent done")
+	exclude := flag.String("exclude", "", "skip pools whose command line matches this regular expression")
+	plan := flag.String("plan", "", "project the finish of a sequential decommission of these pools, in order (e.g. 1,3,2)")
+	showServerInfo := flag.Bool("show-server-info", false, "print a MinIO version/node count banner before the status (one extra API call per poll)")
+	server := flag.String("server", "", "only report pools that include this server, as host or host:port")
+	roundETA := flag.Bool("round-eta", false, "round remaining times to a granularity matching their uncertainty (1m, 15m or 1h)")
+	retryOnEmpty := flag.Int("retry-on-empty", 0, "if no pool is draining at startup, list again this many times, 5s apart, before concluding none is")
+	verify := flag.Bool("verify", false, "check that every decommissioned pool completed and was left empty; exit 0 if so, 1 if not, 2 on error")
+	verifyMaxResidual := flag.String("verify-max-residual", "1GiB", "with -verify, the most data a completed pool may still hold and pass")
+	showRemoved := flag.Bool("show-removed", false, "report the pools in -history-file that the cluster no longer lists, with their final stats, to confirm a removal")
+	isDraining := flag.Bool("is-draining", false, "print only true or false for whether any pool is being decommissioned; exit 0 if so, 1 if not, 2 on error")
+	percent := flag.Bool("percent", false, "print only the lowest progress of the draining pools (or -percent-pool's) as a whole percent; exit 1 if there is none, 2 on error")
+	percentPool := flag.Int("percent-pool", 0, "with -percent, the pool (by number) to print the progress of")
+	reportWebhook := flag.String("report-webhook", "", "with -wait-all, POST a summary of the finished drains to this URL when the watch exits")
+	waitAll := flag.Bool("wait-all", false, "watch until every pool draining at startup has finished; exit non-zero unless all completed")
+	costPerGB := flag.Float64("cost-per-gb", 0, "print the estimated cost of moving the data left on each draining pool at this price per GB, for tiers that charge for egress")
+	minFree := flag.Float64("min-free", 10, "with -watch, warn when a pool receiving data is projected below this percentage free by the end of the drain (0: off)")
+	locale := flag.String("locale", "", "format numbers with this locale's separators and decimal mark (e.g. de-DE)")
+	compactJSON := flag.Bool("compact-json", false, "leave null and zero fields out of JSON output (-json, -jsonl, -output-file, -webhook)")
+	jsonFields := flag.String("json-fields", "", "comma-separated pool fields to keep in JSON output (e.g. id,progressPercent,etaSeconds), leaving out the others")
+	protoOut := flag.Bool("proto", false, "write each poll as a size-delimited protobuf Report (statuspb/status.proto) instead of text")
+	influx := flag.Bool("influx", false, "print one InfluxDB line protocol point per draining pool instead of text (for telegraf exec inputs)")
+	warmupSamples := flag.Int("warmup-samples", 1, "in watch mode, leave this many first samples per pool out of the recent-speed and range estimates")
+	verbose := flag.Bool("verbose", false, "also show the raw usage of each erasure set of draining pools (one extra API call per poll)")
+	etaTemplate := flag.String("eta-template", "", "compute the remaining seconds with this Go template instead of the built-in estimate, e.g. '{{div .Remaining .Speed}}'")
+	noETA := flag.Bool("no-eta", false, "don't estimate completion times; show only progress and speed")
+	preset := flag.String("preset", "", "apply a named bundle of flag defaults: minimal, detailed or ops; explicit flags still win")
+	rawBytes := flag.Bool("raw-bytes", false, "print exact byte counts instead of humanized sizes")
+	onlyChanges := flag.Bool("only-changes", false, "with -jsonl, print a pool only when its free space changed since the last poll")
+	refreshOnSIGHUP := flag.Bool("refresh-on-sighup", false, "in watch mode, poll right away on SIGHUP instead of waiting for the next interval")
+	histogram := flag.Bool("histogram", false, "with -watch, print a histogram of interval speeds to stderr on exit and on SIGUSR1")
+	etaAlert := flag.Duration("eta-alert", 0, "flag draining pools whose ETA is further away than this (e.g. 48h)")
+	etaAlertWebhook := flag.String("eta-alert-webhook", "", "with -eta-alert, POST an alert event to this URL when a pool's ETA goes over the limit")
+	etaAlertExit := flag.Bool("eta-alert-exit", false, "with -eta-alert, exit with status 3 as soon as a pool's ETA is over the limit")
+	head := flag.Int("head", 0, "show only the first n pools in the text output")
+	tail := flag.Int("tail", 0, "show only the last n pools in the text output")
+	estimateFor := flag.String("estimate-for", "", "print how long moving this much data (e.g. 40TiB) would take at -at-speed, without contacting a cluster")
+	atSpeed := flag.String("at-speed", "", "speed per second (e.g. 500MiB) for -estimate-for")
+	runID := flag.String("run-id", "", "tag JSON output and events with this ID, to pick out this invocation in merged logs (default: a random UUID)")
+	showIdentity := flag.Bool("show-identity", false, "print the alias URL and access key in use to stderr (never the secret key)")
+	aggregateMode := flag.String("aggregate-mode", aggregateMax, "ETA for several pools draining at once: max (the latest pool ETA) or combined (all data left at the combined speed)")
+	fromSize := flag.String("from-size", "", "data left on a draining pool at the first of two readings (e.g. 700GiB), for an ETA without contacting a cluster")
+	toSize := flag.String("to-size", "", "data left at the second reading, for -from-size")
+	over := flag.Duration("over", 0, "time between the -from-size and -to-size readings")
+	totalSize := flag.String("total", "", "optionally, the data on the pool when the drain started, for -from-size progress")
+	fleetETA := flag.Bool("fleet-eta", false, "after the status of every alias given, print when all their draining pools should be done (the latest ETA)")
+	noBanner := flag.Bool("no-banner", false, "print only the pool status blocks, without the surrounding messages, totals and blank lines, for embedding in another display")
+	summaryOnly := flag.Bool("summary-only", false, "print one line with the combined progress and ETA of the draining pools instead of each pool")
+	glyphs := flag.Bool("glyphs", false, "print one line per cluster with a character per decommissioned pool for its state, in ASCII unless the locale is UTF-8")
+	fixedWidth := flag.Bool("fixed-width", false, "pad sizes, percentages, speeds and remaining times to a fixed width so they stay aligned between polls")
+	timeStyle := flag.String("time-style", timeStyleHumanize, "how elapsed and remaining times are phrased: humanize (2 hours ago, 1h 26m), precise (2 hours 8 minutes) or compact (~2h)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <alias> [<alias>...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-		if bytesFreed > 0 && initialUsed > 0 && elapsed.Seconds() > 10 {
-			progress := float64(bytesFreed) / float64(initialUsed)
-			speed := float64(bytesFreed) / elapsed.Seconds()
+	if *runID == "" {
+		*runID = newRunID()
+	}
 
-			fmt.Printf("  Progress: %s / %s freed (%.1f%%)\n",
-				humanize.IBytes(uint64(bytesFreed)),
-				humanize.IBytes(uint64(initialUsed)),
-				progress*100)
-			fmt.Printf("  Current usage: %s / %s (%.1f%%)\n",
-				humanize.IBytes(uint64(usedNow)),
-				humanize.IBytes(uint64(d.TotalSize)),
-				100*float64(usedNow)/float64(d.TotalSize))
-			fmt.Printf("  Speed: %s/sec\n", humanize.IBytes(uint64(speed)))
+	switch *timeStyle {
+	case timeStyleHumanize, timeStylePrecise, timeStyleCompact:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid -time-style %q: want %s, %s or %s\n", *timeStyle, timeStyleHumanize, timeStylePrecise, timeStyleCompact)
+		os.Exit(1)
+	}
 
-			if progress > 0 && progress < 1.0 {
-				totalEstimated := elapsed.Seconds() / progress
-				etaSeconds := totalEstimated - elapsed.Seconds()
-				etaDuration := time.Duration(etaSeconds) * time.Second
-				etaTime := time.Now().Add(etaDuration)
-				fmt.Printf("  ETA: %s (%s remaining)\n",
-					etaTime.Format(time.RFC3339),
-					formatDuration(etaDuration))
+	if *estimateFor != "" || *atSpeed != "" || *fromSize != "" || *toSize != "" {
+		readings := *fromSize != "" || *toSize != ""
+		switch {
+		case readings && (*estimateFor != "" || *atSpeed != ""):
+			fmt.Fprintln(os.Stderr, "Error: -estimate-for and -from-size are separate calculations")
+			os.Exit(1)
+		case readings && (*fromSize == "" || *toSize == "" || *over == 0):
+			fmt.Fprintln(os.Stderr, "Error: -from-size, -to-size and -over go together")
+			os.Exit(1)
+		case !readings && (*estimateFor == "" || *atSpeed == ""):
+			fmt.Fprintln(os.Stderr, "Error: -estimate-for and -at-speed go together")
+			os.Exit(1)
+		case flag.NArg() != 0:
+			fmt.Fprintln(os.Stderr, "Error: -estimate-for and -from-size take no alias")
+			os.Exit(1)
+		}
+		out := outputOptions{format: formatText, precision: *precision, roundETA: *roundETA, timeStyle: *timeStyle}
+		if *locale != "" {
+			var err error
+			if out.printer, err = newPrinter(*locale); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
 			}
+		}
+		var err error
+		if readings {
+			err = out.printReadings(*fromSize, *toSize, *over, *totalSize)
 		} else {
-			fmt.Println("  Decommissioning is starting, ETA not yet available...")
+			err = out.printEstimate(*estimateFor, *atSpeed)
 		}
-		fmt.Println()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	if !hasDraining {
-		fmt.Println("No pools are currently being decommissioned.")
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
 	}
-}
 
-func main() {
-	configDir := flag.String("config-dir", "", "path to mc config directory (default: ~/.mc)")
-	watch := flag.Bool("watch", false, "continuously monitor decommission status (every 10s)")
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <alias>\n", os.Args[0])
-		flag.PrintDefaults()
+	aliases := flag.Args()
+	alias := aliases[0]
+
+	if *preset != "" {
+		if err := applyPreset(flag.CommandLine, *preset); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
-	flag.Parse()
 
-	if flag.NArg() != 1 {
-		flag.Usage()
+	switch *etaBasis {
+	case basisBytes:
+	case basisObjects, etaBasisBoth:
+		// The admin API reports objects moved but not how many there are.
+		if *totalObjects <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: -eta-basis %s requires -total-objects\n", *etaBasis)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid -eta-basis %q: want %q, %q or %q\n", *etaBasis, basisBytes, basisObjects, etaBasisBoth)
 		os.Exit(1)
 	}
 
-	alias := flag.Arg(0)
-
-	ac, err := loadAlias(alias, *configDir)
+	ac, err := loadAlias(alias, *configDir, configFiles)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	client, err := newAdminClient(ac)
+	if (*accessKey == "") != (*secretKey == "") || (*sessionToken != "" && *accessKey == "") {
+		fmt.Fprintln(os.Stderr, "Error: -access-key and -secret-key must be used together, and -session-token needs them")
+		os.Exit(1)
+	}
+	copts := clientOptions{
+		clientCert:   *clientCert,
+		clientKey:    *clientKey,
+		headers:      http.Header{},
+		accessKey:    *accessKey,
+		secretKey:    *secretKey,
+		sessionToken: *sessionToken,
+	}
+	for _, h := range headers {
+		k, v, err := parseHeader(h)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		copts.headers.Add(k, v)
+	}
+	if *showIdentity {
+		fmt.Fprintln(os.Stderr, identity(alias, ac, copts))
+	}
+	client, err := newAdminClient(ac, copts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating admin client: %v\n", err)
 		os.Exit(1)
 	}
+	// newAdminClient has checked the options already.
+	transport, _ := newTransport(ac, copts)
 
-	if !*watch {
-		printStatus(client)
+	if *precision < 0 || *precision > 6 {
+		fmt.Fprintf(os.Stderr, "Error: invalid -precision %d: want 0 to 6\n", *precision)
+		os.Exit(1)
+	}
+
+	switch {
+	case *head > 0 && *tail > 0:
+		fmt.Fprintln(os.Stderr, "Error: -head and -tail are mutually exclusive")
+		os.Exit(1)
+	case *head < 0 || *tail < 0:
+		fmt.Fprintln(os.Stderr, "Error: -head and -tail want a positive number of pools")
+		os.Exit(1)
+	}
+
+	switch *aggregateMode {
+	case aggregateMax, aggregateCombined:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid -aggregate-mode %q: want %s or %s\n", *aggregateMode, aggregateMax, aggregateCombined)
+		os.Exit(1)
+	}
+
+	format := formatText
+	switch {
+	case countTrue(*jsonOut, *jsonlOut, *influx, *protoOut) > 1:
+		fmt.Fprintln(os.Stderr, "Error: -json, -jsonl, -influx and -proto are mutually exclusive")
+		os.Exit(1)
+	case *jsonOut:
+		format = formatJSON
+	case *jsonlOut:
+		format = formatJSONL
+	case *influx:
+		format = formatInflux
+	case *protoOut:
+		format = formatProto
+	}
+
+	m := &monitor{
+		client:     client,
+		transport:  transport,
+		alias:      alias,
+		runID:      *runID,
+		dumpRaw:    *dumpRawPath,
+		showServer: *showServerInfo,
+		verbose:    *verbose,
+		drives:     newDriveTracker(),
+		out: outputOptions{
+			format:           format,
+			precision:        *precision,
+			roundETA:         *roundETA,
+			json:             jsonShape{compact: *compactJSON},
+			noETA:            *noETA,
+			rawBytes:         *rawBytes,
+			onlyChanges:      *onlyChanges,
+			follow:           *follow,
+			fixedWidth:       *fixedWidth,
+			summaryOnly:      *summaryOnly,
+			glyphs:           glyphSetFor(*glyphs),
+			noBanner:         *noBanner,
+			verbose:          *verbose,
+			color:            *follow && !*plain && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout),
+			etaAlert:         *etaAlert,
+			costPerGB:        *costPerGB,
+			head:             *head,
+			timeStyle:        *timeStyle,
+			aggregateMode:    *aggregateMode,
+			tail:             *tail,
+			summarizeCmdLine: *summarizeCmdLine,
+			quiet:            *quiet,
+			list:             *list,
+			fields:           *fields,
+		},
+	}
+
+	if *locale != "" {
+		m.out.printer, err = newPrinter(*locale)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *jsonFields != "" {
+		if m.out.json.fields, err = parseJSONFields(*jsonFields); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *match != "" {
+		m.filter.match, err = regexp.Compile(*match)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -match: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	m.filter.server = *server
+	for _, p := range []struct {
+		name  string
+		value float64
+	}{{"progress-above", *progressAbove}, {"progress-below", *progressBelow}} {
+		if p.value < 0 || p.value > 100 {
+			fmt.Fprintf(os.Stderr, "Error: invalid -%s %g: want 0 to 100\n", p.name, p.value)
+			os.Exit(1)
+		}
+	}
+	if *progressAbove > 0 && *progressBelow > 0 && *progressAbove >= *progressBelow {
+		fmt.Fprintf(os.Stderr, "Error: -progress-above %g and -progress-below %g leave no pool to report\n", *progressAbove, *progressBelow)
+		os.Exit(1)
+	}
+	m.filter.progressAbove, m.filter.progressBelow = *progressAbove, *progressBelow
+	if *exclude != "" {
+		m.filter.exclude, err = regexp.Compile(*exclude)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -exclude: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *plan != "" {
+		if *noETA {
+			fmt.Fprintln(os.Stderr, "Error: -plan and -no-eta are mutually exclusive")
+			os.Exit(1)
+		}
+		m.plan, err = parsePlan(*plan)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *diffSince {
+		m.state, err = loadState(*stateFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	switch *etaBasis {
+	case basisObjects:
+		m.objectBasisTotal = *totalObjects
+	case etaBasisBoth:
+		m.bothBasisObjectTotal = *totalObjects
+	}
+
+	if *waitAll || *follow {
+		*watch = true
+	}
+	if *isDraining && (*watch || *list) {
+		fmt.Fprintln(os.Stderr, "Error: -is-draining cannot be combined with -watch, -wait-all or -list")
+		os.Exit(1)
+	}
+	if *percent && (*watch || *list || *isDraining) {
+		fmt.Fprintln(os.Stderr, "Error: -percent cannot be combined with -watch, -wait-all, -list or -is-draining")
+		os.Exit(1)
+	}
+	if *percentPool != 0 && (!*percent || *percentPool < 0) {
+		fmt.Fprintln(os.Stderr, "Error: -percent-pool takes a pool number and requires -percent")
+		os.Exit(1)
+	}
+	if *verify && (*watch || *list || *isDraining || *percent) {
+		fmt.Fprintln(os.Stderr, "Error: -verify cannot be combined with -watch, -wait-all, -list, -is-draining or -percent")
+		os.Exit(1)
+	}
+	if *showRemoved && (*historyFile == "" || *watch || *list || *isDraining || *percent || *verify || format != formatText) {
+		fmt.Fprintln(os.Stderr, "Error: -show-removed requires -history-file and prints text; it cannot be combined with -watch, -list, -is-draining, -percent, -verify, -json, -jsonl, -influx or -proto")
+		os.Exit(1)
+	}
+	maxResidual, err := humanize.ParseBytes(*verifyMaxResidual)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -verify-max-residual: %v\n", err)
+		os.Exit(1)
+	}
+	if *reportWebhook != "" && !*waitAll {
+		fmt.Fprintln(os.Stderr, "Error: -report-webhook requires -wait-all")
+		os.Exit(1)
+	}
+
+	if *warmupSamples < 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid -warmup-samples %d: want 0 or more\n", *warmupSamples)
+		os.Exit(1)
+	}
+	if *costPerGB < 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid -cost-per-gb %g: want a price of 0 or more\n", *costPerGB)
+		os.Exit(1)
+	}
+	if *minFree < 0 || *minFree > 100 {
+		fmt.Fprintf(os.Stderr, "Error: invalid -min-free %g: want 0 to 100\n", *minFree)
+		os.Exit(1)
+	}
+	// Fill rates need successive polls, so one-shot runs have none.
+	if *watch && *minFree > 0 {
+		m.fill = newFillTracker(*minFree)
+	}
+
+	// Watch mode always keeps samples in memory for the recent-speed
+	// estimate; -history-file additionally persists them.
+	if *comparePrior && *historyFile == "" && !*watch {
+		fmt.Fprintln(os.Stderr, "Error: -compare-to-previous-pool requires -history-file or -watch")
+		os.Exit(1)
+	}
+	m.comparePrior = *comparePrior
+	if *historyPrior && *historyFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: -history-prior requires -history-file")
+		os.Exit(1)
+	}
+	m.historyPrior = *historyPrior
+	if *etaTemplate != "" {
+		if *noETA {
+			fmt.Fprintln(os.Stderr, "Error: -eta-template and -no-eta are mutually exclusive")
+			os.Exit(1)
+		}
+		if m.formula, err = parseETAFormula(*etaTemplate); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -eta-template: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *retryOnEmpty < 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid -retry-on-empty %d: want 0 or more\n", *retryOnEmpty)
+		os.Exit(1)
+	}
+	m.retryEmpty = *retryOnEmpty
+	if *historyFile != "" || *watch {
+		m.history, err = loadHistory(*historyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// A one-shot run only ever takes one sample, which must count.
+		if *watch {
+			m.history.warmup = *warmupSamples
+		}
+		// A restarted watcher picks up where it left off; say so, as its
+		// estimates won't look like a fresh start's.
+		if *watch && !*quiet && m.history.len() > 0 {
+			fmt.Fprintf(os.Stderr, "Resuming from %s in %s\n", plural(m.history.len(), "sample", "samples"), *historyFile)
+		}
+	}
+
+	if *atTime != "" {
+		switch {
+		case *historyFile == "":
+			fmt.Fprintln(os.Stderr, "Error: -at-time requires -history-file")
+			os.Exit(1)
+		case *watch || *diffSince || *isDraining || *percent || *verify || *showRemoved || *list || *fields || *dumpRawPath != "" || *showServerInfo || *verbose:
+			fmt.Fprintln(os.Stderr, "Error: -at-time replays the history and cannot be combined with -watch, -diff-since, -is-draining, -percent, -verify, -show-removed, -list, -fields, -dump-raw, -show-server-info or -verbose, which need the live cluster")
+			os.Exit(1)
+		case len(aliases) > 1:
+			fmt.Fprintln(os.Stderr, "Error: -at-time takes a single alias")
+			os.Exit(1)
+		}
+		at, err := parseAtTime(*atTime, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		var ok bool
+		if m.at, ok = m.history.lastPollAt(alias, at); !ok {
+			fmt.Fprintf(os.Stderr, "Error: %s has no samples of %s at or before %s\n", *historyFile, alias, at.Format(time.RFC3339))
+			os.Exit(1)
+		}
+		m.history.truncate(m.at)
+		if !*quiet && format == formatText {
+			fmt.Fprintf(os.Stderr, "As of %s, from %s\n", m.at.Format(time.RFC3339), *historyFile)
+		}
+	}
+
+	if *since != "" {
+		if *historyFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: -since requires -history-file")
+			os.Exit(1)
+		}
+		m.since, err = parseSince(*since, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !*quiet {
+		m.reporters = append(m.reporters, &consoleReporter{out: m.out, state: m.state, lastSize: map[string]int64{}})
+	}
+	if *outputFile != "" {
+		m.reporters = append(m.reporters, &fileReporter{path: *outputFile, shape: m.out.json, csv: *csvOut})
+	} else if *csvOut {
+		fmt.Fprintln(os.Stderr, "Error: -csv requires -output-file")
+		os.Exit(1)
+	}
+	if *teeJSON != "" {
+		m.reporters = append(m.reporters, &teeReporter{path: *teeJSON, shape: m.out.json})
+	}
+	if *webhook != "" {
+		m.reporters = append(m.reporters, newWebhookReporter(*webhook, m.out.json))
+	}
+	if *sqlitePath != "" {
+		sr, err := newSQLiteReporter(*sqlitePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		m.reporters = append(m.reporters, sr)
+	}
+	if *metricsAddr != "" {
+		if !*watch {
+			fmt.Fprintln(os.Stderr, "Error: -metrics-addr requires -watch")
+			os.Exit(1)
+		}
+		// A healthy watch polls every interval; allow for a slow poll or
+		// two before /healthz gives up on it.
+		mr, err := newMetricsReporter(*metricsAddr, 3**interval)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		m.metrics = mr
+		m.reporters = append(m.reporters, mr)
+	}
+	if *etaAlert > 0 {
+		if *noETA {
+			fmt.Fprintln(os.Stderr, "Error: -eta-alert and -no-eta are mutually exclusive")
+			os.Exit(1)
+		}
+		m.alert = newETAAlerter(*etaAlert, *etaAlertWebhook, *etaAlertExit)
+		m.reporters = append(m.reporters, m.alert)
+	} else if *etaAlertWebhook != "" || *etaAlertExit {
+		fmt.Fprintln(os.Stderr, "Error: -eta-alert-webhook and -eta-alert-exit require -eta-alert")
+		os.Exit(1)
+	}
+	if *eventLog != "" {
+		if !*watch {
+			fmt.Fprintln(os.Stderr, "Error: -event-log requires -watch")
+			os.Exit(1)
+		}
+		m.reporters = append(m.reporters, newTimelineReporter(*eventLog))
+	}
+	if *natsURL != "" {
+		ep, err := newEventPublisher(*natsURL, *natsSubject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		m.reporters = append(m.reporters, ep)
+	}
+
+	floor := minPollInterval
+	if v := os.Getenv(minIntervalEnv); v != "" {
+		if floor, err = time.ParseDuration(v); err != nil || floor <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid %s %q: want a positive duration\n", minIntervalEnv, v)
+			os.Exit(1)
+		}
+	}
+	if *interval < floor {
+		fmt.Fprintf(os.Stderr, "Warning: -interval %s is below the %s minimum, polling every %s (set %s to lower it)\n",
+			*interval, floor, floor, minIntervalEnv)
+		*interval = floor
+	}
+
+	if *refreshOnSIGHUP && (!*watch || refreshSignal == nil) {
+		fmt.Fprintln(os.Stderr, "Error: -refresh-on-sighup requires -watch, on a system with SIGHUP")
+		os.Exit(1)
+	}
+	if *histogram && !*watch {
+		fmt.Fprintln(os.Stderr, "Error: -histogram requires -watch")
+		os.Exit(1)
+	}
+	if *onlyChanges && format != formatJSONL {
+		fmt.Fprintln(os.Stderr, "Error: -only-changes requires -jsonl")
+		os.Exit(1)
+	}
+	if *follow && format != formatText {
+		fmt.Fprintln(os.Stderr, "Error: -follow prints text and cannot be combined with -json, -jsonl, -influx or -proto")
+		os.Exit(1)
+	}
+	if *noBanner && (format != formatText || *summaryOnly || *follow || *list) {
+		fmt.Fprintln(os.Stderr, "Error: -no-banner applies to the text status and cannot be combined with -json, -jsonl, -influx, -proto, -summary-only, -follow or -list")
+		os.Exit(1)
+	}
+	if *summaryOnly && (format != formatText || *follow || *list) {
+		fmt.Fprintln(os.Stderr, "Error: -summary-only prints text and cannot be combined with -json, -jsonl, -influx, -proto, -follow or -list")
+		os.Exit(1)
+	}
+	if *fields && (format != formatText || *summaryOnly || *glyphs || *noBanner || *follow || *list) {
+		fmt.Fprintln(os.Stderr, "Error: -fields prints text and cannot be combined with -json, -jsonl, -influx, -proto, -summary-only, -glyphs, -no-banner, -follow or -list")
+		os.Exit(1)
+	}
+	if *glyphs && (format != formatText || *summaryOnly || *noBanner || *follow || *list) {
+		fmt.Fprintln(os.Stderr, "Error: -glyphs prints text and cannot be combined with -json, -jsonl, -influx, -proto, -summary-only, -no-banner, -follow or -list")
+		os.Exit(1)
+	}
+	if *heartbeat > 0 && !(*watch && (*quiet || *onlyChanges || *follow)) {
+		fmt.Fprintln(os.Stderr, "Error: -heartbeat requires -watch and one of -quiet, -only-changes or -follow")
+		os.Exit(1)
+	}
+
+	if *fleetETA && (format != formatText || *quiet || *list) {
+		fmt.Fprintln(os.Stderr, "Error: -fleet-eta prints text and cannot be combined with -json, -jsonl, -influx, -proto, -quiet or -list")
+		os.Exit(1)
+	}
+	// Further aliases are polled with the same settings, sharing the
+	// reporters, history and state.
+	monitors := []*monitor{m}
+	if len(aliases) > 1 {
+		switch {
+		case *watch:
+			fmt.Fprintln(os.Stderr, "Error: several aliases can only be polled once; run a watcher per alias for -watch, -wait-all and -follow")
+			os.Exit(1)
+		case *isDraining || *percent || *verify || *showRemoved || *dumpRawPath != "" || len(m.plan) > 0:
+			fmt.Fprintln(os.Stderr, "Error: -is-draining, -percent, -verify, -show-removed, -dump-raw and -plan take a single alias")
+			os.Exit(1)
+		}
+		for _, a := range aliases[1:] {
+			ac, err := loadAlias(a, *configDir, configFiles)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if *showIdentity {
+				fmt.Fprintln(os.Stderr, identity(a, ac, copts))
+			}
+			fm := *m
+			fm.alias = a
+			// Drives are keyed by endpoint, which clusters may share.
+			fm.drives = newDriveTracker()
+			if fm.client, err = newAdminClient(ac, copts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating admin client for %s: %v\n", a, err)
+				os.Exit(1)
+			}
+			fm.transport, _ = newTransport(ac, copts)
+			monitors = append(monitors, &fm)
+		}
+	}
+
+	if *isDraining {
+		draining, err := m.draining()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Println(draining)
+		if !draining {
+			os.Exit(1)
+		}
 		return
 	}
 
-	for {
-		fmt.Print("\033[H\033[2J")
-		printStatus(client)
-		time.Sleep(10 * time.Second)
+	if *percent {
+		pct, ok, err := m.percent(*percentPool)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		fmt.Println(pct)
+		return
 	}
-}
 
-func formatDuration(d time.Duration) string {
-	days := int(d.Hours()) / 24
-	hours := int(d.Hours()) % 24
-	mins := int(d.Minutes()) % 60
+	if *verify {
+		results, err := m.verify(int64(maxResidual))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if !m.out.printVerify(results, int64(maxResidual)) {
+			os.Exit(1)
+		}
+		return
+	}
 
-	var parts []string
-	if days > 0 {
-		parts = append(parts, fmt.Sprintf("%dd", days))
+	if *showRemoved {
+		removed, err := m.showRemoved()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		m.out.printRemoved(removed, *historyFile)
+		return
 	}
-	if hours > 0 {
-		parts = append(parts, fmt.Sprintf("%dh", hours))
+
+	if !*watch {
+		if !pollFleet(monitors, *fleetETA) {
+			os.Exit(1)
+		}
+		if m.alert.tripped() {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", errETAExceeded)
+			os.Exit(3)
+		}
+		return
 	}
-	if mins > 0 {
-		parts = append(parts, fmt.Sprintf("%dm", mins))
+
+	wopts := watchOptions{
+		maxErrors:       *maxErrors,
+		plain:           *plain,
+		heartbeat:       *heartbeat,
+		interval:        *interval,
+		waitAll:         *waitAll,
+		histogram:       *histogram,
+		maxRetryDelay:   *maxRetryDelay,
+		reportWebhook:   *reportWebhook,
+		refreshOnSignal: *refreshOnSIGHUP,
+		reconnect: func() (*madmin.AdminClient, error) {
+			return newAdminClient(ac, copts)
+		},
 	}
-	if len(parts) == 0 {
-		return "< 1m"
+	if err := m.watch(wopts); err != nil {
+		if errors.Is(err, errInterrupted) {
+			os.Exit(130)
+		}
+		if errors.Is(err, errETAExceeded) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(3)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func countTrue(flags ...bool) int {
+	n := 0
+	for _, f := range flags {
+		if f {
+			n++
+		}
 	}
-	return strings.Join(parts, " ")
+	return n
 }
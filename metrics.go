@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsReporter serves the latest report in the Prometheus text format,
+// and the health of the watch on /healthz.
+type metricsReporter struct {
+	mu     sync.Mutex
+	latest *pollReport
+	// lastErr is the error of the last failed poll, at lastErrTime.
+	lastErr     error
+	lastErrTime time.Time
+	// staleAfter is how old the last successful poll may get before the
+	// watch is reported unhealthy.
+	staleAfter time.Duration
+}
+
+// newMetricsReporter starts serving /metrics and /healthz on addr. The
+// listener is opened here so a bad address fails at startup rather than in
+// the background.
+func newMetricsReporter(addr string, staleAfter time.Duration) (*metricsReporter, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics listen: %w", err)
+	}
+	mr := &metricsReporter{staleAfter: staleAfter}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", mr.serveMetrics)
+	mux.HandleFunc("/healthz", mr.serveHealth)
+	go http.Serve(ln, mux)
+	return mr, nil
+}
+
+func (mr *metricsReporter) report(r *pollReport) error {
+	mr.mu.Lock()
+	mr.latest = r
+	mr.mu.Unlock()
+	return nil
+}
+
+// failed records a failed poll for /healthz.
+func (mr *metricsReporter) failed(err error) {
+	if mr == nil {
+		return
+	}
+	mr.mu.Lock()
+	mr.lastErr, mr.lastErrTime = err, time.Now()
+	mr.mu.Unlock()
+}
+
+// health is the /healthz body. LastError is kept once a later poll
+// succeeds, for debugging an intermittent failure.
+type health struct {
+	Status        string     `json:"status"`
+	Alias         string     `json:"alias,omitempty"`
+	LastPoll      *time.Time `json:"lastPoll"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+}
+
+// serveHealth answers 200 while the last poll succeeded less than
+// staleAfter ago, and 503 before the first poll succeeds, after a failed
+// one or once polls stop coming, so that a liveness or readiness probe can
+// act on a watch that lost its cluster or hung.
+func (mr *metricsReporter) serveHealth(w http.ResponseWriter, _ *http.Request) {
+	mr.mu.Lock()
+	r, lastErr, lastErrTime := mr.latest, mr.lastErr, mr.lastErrTime
+	mr.mu.Unlock()
+
+	h := health{Status: "ok"}
+	code := http.StatusOK
+	if r != nil {
+		h.Alias = r.Alias
+		h.LastPoll = &r.Time
+	}
+	if lastErr != nil {
+		h.LastError = lastErr.Error()
+		h.LastErrorTime = &lastErrTime
+	}
+	switch {
+	case r == nil && lastErr == nil:
+		h.Status, code = "no poll yet", http.StatusServiceUnavailable
+	case r == nil || lastErr != nil && lastErrTime.After(r.Time):
+		h.Status, code = "last poll failed", http.StatusServiceUnavailable
+	case time.Since(r.Time) > mr.staleAfter:
+		h.Status, code = "last poll too old", http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(h)
+}
+
+// poolMetrics lists the per-pool gauges in output order.
+var poolMetrics = []struct {
+	name, help string
+	value      func(s decomStatus) (float64, bool)
+}{
+	{"decom_eta_pool_total_bytes", "Total capacity of the pool.",
+		func(s decomStatus) (float64, bool) { return float64(s.TotalSize), true }},
+	{"decom_eta_pool_initial_used_bytes", "Bytes that had to move off the pool when the decommission started.",
+		func(s decomStatus) (float64, bool) { return float64(s.InitialUsed), true }},
+	{"decom_eta_pool_freed_bytes", "Free space gained since the decommission started.",
+		func(s decomStatus) (float64, bool) { return float64(s.BytesFreed), true }},
+	{"decom_eta_pool_used_bytes", "Bytes still used on the pool.",
+		func(s decomStatus) (float64, bool) { return float64(s.UsedNow), true }},
+	{"decom_eta_pool_objects_moved", "Objects moved off the pool.",
+		func(s decomStatus) (float64, bool) { return float64(s.ObjectsDone), true }},
+	{"decom_eta_pool_objects_failed", "Objects that failed to move.",
+		func(s decomStatus) (float64, bool) { return float64(s.ObjectsFailed), true }},
+	{"decom_eta_pool_progress_ratio", "Decommission progress between 0 and 1.",
+		func(s decomStatus) (float64, bool) { return s.Progress, s.HasProgress }},
+	{"decom_eta_pool_speed", "Drain speed, in bytes/sec or objects/sec depending on the basis label.",
+		func(s decomStatus) (float64, bool) { return s.Speed, s.HasProgress }},
+	{"decom_eta_pool_eta_seconds", "Estimated seconds until the decommission completes.",
+		func(s decomStatus) (float64, bool) { return s.ETA.Seconds(), s.HasETA }},
+}
+
+func (mr *metricsReporter) serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	mr.mu.Lock()
+	r := mr.latest
+	mr.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if r == nil {
+		return
+	}
+	writeMetrics(w, r)
+}
+
+func writeMetrics(w io.Writer, r *pollReport) {
+	active := r.active()
+	alias := escapeLabel(r.Alias)
+
+	fmt.Fprintln(w, "# HELP decom_eta_pools_draining Number of pools currently being decommissioned.")
+	fmt.Fprintln(w, "# TYPE decom_eta_pools_draining gauge")
+	fmt.Fprintf(w, "decom_eta_pools_draining{alias=\"%s\"} %d\n", alias, len(active))
+
+	// Reports only come from successful polls, so a timestamp that stops
+	// advancing means the watch is hung or can't reach the cluster.
+	fmt.Fprintln(w, "# HELP decom_eta_last_poll_timestamp_seconds Unix time of the last successful poll.")
+	fmt.Fprintln(w, "# TYPE decom_eta_last_poll_timestamp_seconds gauge")
+	fmt.Fprintf(w, "decom_eta_last_poll_timestamp_seconds{alias=\"%s\"} %s\n", alias,
+		strconv.FormatFloat(float64(r.Time.UnixNano())/1e9, 'f', 3, 64))
+
+	for _, pm := range poolMetrics {
+		fmt.Fprintf(w, "# HELP %s %s\n", pm.name, pm.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", pm.name)
+		for _, s := range active {
+			v, ok := pm.value(s)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "%s{alias=\"%s\",pool=\"%d\",cmdline=\"%s\",basis=\"%s\"} %s\n",
+				pm.name, alias, s.ID+1, escapeLabel(s.CmdLine), s.Basis,
+				strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
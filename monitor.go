@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// monitor polls a cluster and hands the computed status of each poll to its
+// reporters.
+type monitor struct {
+	client    *madmin.AdminClient
+	transport http.RoundTripper // the client's, for probes outside the admin API
+	alias     string
+	runID     string // -run-id, shared by the monitors of every alias
+	reporters []reporter
+	filter    poolFilter
+	plan      []int      // -plan pool order, 1-based
+	state     *stateFile // nil unless -diff-since
+	history   *history   // samples, in memory in watch mode; nil otherwise unless -history-file
+	since     time.Time  // only consider progress after this, if set
+	dumpRaw   string     // -dump-raw destination, "-" for stdout
+	// objectBasisTotal, with -eta-basis objects, switches the estimates to
+	// the object basis.
+	objectBasisTotal int64
+	// bothBasisObjectTotal, with -eta-basis both, adds an object estimate
+	// to the bytes one.
+	bothBasisObjectTotal int64
+	alert                *etaAlerter      // nil unless -eta-alert
+	metrics              *metricsReporter // nil unless -metrics-addr
+	// fill warns about receiving pools running out of space; nil unless
+	// watching with -min-free.
+	fill *fillTracker
+	// showServer adds a ServerInfo banner to every report.
+	showServer bool
+	// verbose adds the erasure sets and drives of draining pools, also
+	// from ServerInfo.
+	verbose bool
+	drives  *driveTracker // set with verbose
+	// comparePrior gives a new drain a provisional ETA at the speed of the
+	// last pool that completed.
+	comparePrior bool
+	// historyPrior bases the ETA of a drain that hasn't settled yet on the
+	// average speed of the cluster's completed drains in the history.
+	historyPrior bool
+	// retryEmpty is how many more times the first listing is retried while
+	// it shows nothing draining.
+	retryEmpty int
+	// formula, from -eta-template, replaces the built-in ETA; nil otherwise.
+	formula *etaFormula
+	last    []decomStatus // statuses from the latest successful poll
+	// at, with -at-time, is the poll of the history to replay instead of
+	// asking the cluster; the history then ends there.
+	at  time.Time
+	out outputOptions
+}
+
+// listPools fetches the pool status.
+func (m *monitor) listPools() ([]madmin.PoolStatus, error) {
+	if !m.at.IsZero() {
+		return m.history.snapshot(m.alias, m.at), nil
+	}
+	pools, err := m.client.ListPoolsStatus(context.Background())
+	if err != nil {
+		return nil, m.listError(err)
+	}
+	// Right after a decommission is started the listing can lag behind,
+	// so on startup give it a few chances to show the drain.
+	for ; m.retryEmpty > 0; m.retryEmpty-- {
+		if anyDraining(m.filter.apply(pools)) {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "No pools draining yet, retrying in %s (%d left)\n", emptyRetryDelay, m.retryEmpty)
+		time.Sleep(emptyRetryDelay)
+		if pools, err = m.client.ListPoolsStatus(context.Background()); err != nil {
+			return nil, m.listError(err)
+		}
+	}
+	m.retryEmpty = 0
+	return pools, nil
+}
+
+// listError wraps a failed ListPoolsStatus, explaining the errors that
+// point at a misconfiguration.
+func (m *monitor) listError(err error) error {
+	switch {
+	case isAccessDenied(err):
+		err = &accessDeniedError{err: err, actions: []string{"admin:ServerInfo", "admin:Decommission"}}
+	case mayBeConsole(err) && isConsole(m.client.GetEndpointURL(), m.transport):
+		err = &consoleEndpointError{err: err, endpoint: m.client.GetEndpointURL().String()}
+	case isVersionMismatch(err):
+		err = &versionMismatchError{err: err, serverVersion: serverVersion(m.client)}
+	}
+	return fmt.Errorf("list pool status: %w", err)
+}
+
+// emptyRetryDelay is the wait between -retry-on-empty attempts.
+const emptyRetryDelay = 5 * time.Second
+
+func anyDraining(pools []madmin.PoolStatus) bool {
+	for _, pool := range pools {
+		if d := pool.Decommission; d != nil && !d.StartTime.IsZero() && decomState(d) == stateActive {
+			return true
+		}
+	}
+	return false
+}
+
+// draining reports whether any pool that passes the filters is being
+// decommissioned, for -is-draining.
+func (m *monitor) draining() (bool, error) {
+	pools, err := m.listPools()
+	if err != nil {
+		return false, err
+	}
+	return anyDraining(m.filter.apply(pools)), nil
+}
+
+// percent is the -percent value: the progress of pool (1-based) as a whole
+// percent, rounded down, or with pool 0 the lowest of the draining pools'.
+// It returns false when there is no such progress yet.
+func (m *monitor) percent(pool int) (int, bool, error) {
+	pools, err := m.listPools()
+	if err != nil {
+		return 0, false, err
+	}
+	lowest, found := 0.0, false
+	for _, s := range m.computeStatuses(m.filter.apply(pools), time.Now()) {
+		if !s.HasProgress || (pool == 0 && s.State != stateActive) || (pool != 0 && s.ID+1 != pool) {
+			continue
+		}
+		if !found || s.Progress < lowest {
+			lowest, found = s.Progress, true
+		}
+	}
+	return int(math.Floor(100 * lowest)), found, nil
+}
+
+func (m *monitor) poll() error {
+	pools, err := m.listPools()
+	if err != nil {
+		return err
+	}
+
+	if m.dumpRaw != "" {
+		if err := dumpRaw(m.dumpRaw, pools); err != nil {
+			return fmt.Errorf("dump raw response: %w", err)
+		}
+	}
+
+	listed := len(pools)
+	// The cluster's space is that of every pool, whatever the filters, and
+	// a history snapshot doesn't have them all. The receiving pools that
+	// -min-free watches are usually filtered out too.
+	all := pools
+	var cluster *clusterSpace
+	if m.at.IsZero() {
+		cluster = newClusterSpace(all)
+	}
+	pools = m.filter.apply(pools)
+
+	if m.out.list {
+		m.out.printPoolList(pools, listed)
+		return nil
+	}
+	if m.out.fields {
+		m.out.printFields(pools)
+		return nil
+	}
+
+	now := time.Now()
+	if !m.at.IsZero() {
+		now = m.at
+	}
+	statuses := m.computeStatuses(pools, now)
+
+	// A drain that finished between two polls would otherwise just drop
+	// out of the active pools.
+	var finished []decomStatus
+	for _, s := range statuses {
+		if s.State == stateActive {
+			continue
+		}
+		for _, prev := range m.last {
+			if prev.CmdLine == s.CmdLine && prev.State == stateActive && prev.StartTime.Equal(s.StartTime) {
+				finished = append(finished, s)
+			}
+		}
+	}
+	m.last = statuses
+	shown := m.filter.applyStatuses(statuses)
+	report := &pollReport{Time: now, Alias: m.alias, RunID: m.runID, Pools: shown, Listed: listed, Kept: len(pools), Cluster: cluster,
+		Finished: m.filter.applyStatuses(finished), ProgressHidden: len(statuses) - len(shown)}
+	if len(m.plan) > 0 {
+		report.Plan = computePlan(m.plan, pools, statuses)
+	}
+	if m.fill != nil {
+		report.Capacity = m.fill.check(m.alias, all, statuses, now)
+	}
+	if m.showServer || m.verbose {
+		// Server info is context only; don't fail the poll over it.
+		if info, err := fetchServerInfo(m.client); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		} else {
+			if m.showServer {
+				b := newServerBanner(info)
+				report.Server = &b
+			}
+			if m.verbose {
+				report.Sets = poolSets(info)
+				report.Drives = m.drives.poolDrives(info, now)
+			}
+		}
+	}
+	for _, r := range m.reporters {
+		// One failing sink (a down webhook, say) shouldn't stop the others.
+		if err := r.report(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}
+
+	if m.state != nil {
+		for _, s := range statuses {
+			if s.State != stateActive {
+				continue
+			}
+			m.state.Pools[stateKey(m.alias, s.CmdLine)] = poolState{CurrentSize: s.CurrentSize, Time: now, StartTime: s.StartTime}
+		}
+		if err := m.state.save(); err != nil {
+			return fmt.Errorf("save state: %w", err)
+		}
+	}
+	return nil
+}
+
+// computeStatuses derives the status of every decommissioned pool and
+// records the poll in the history.
+func (m *monitor) computeStatuses(pools []madmin.PoolStatus, now time.Time) []decomStatus {
+	var statuses []decomStatus
+	for _, pool := range pools {
+		if s, ok := computeStatus(pool, now); ok {
+			m.checkRestart(&s)
+			if err := m.estimate(&s, now); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			statuses = append(statuses, s)
+		}
+	}
+
+	if m.history != nil {
+		if err := m.history.record(m.alias, pools, now); err != nil {
+			fmt.Fprintf(os.Stderr, "Error recording history: %v\n", err)
+		}
+	}
+	return statuses
+}
+
+// checkRestart flags a decommission restarted since the state file or the
+// history last saw it, and forgets the history of the earlier run: its
+// samples would corrupt the estimates for the new one.
+func (m *monitor) checkRestart(s *decomStatus) {
+	key := stateKey(m.alias, s.CmdLine)
+	if m.state != nil {
+		if prev, ok := m.state.Pools[key]; ok && !prev.StartTime.IsZero() && !prev.StartTime.Equal(s.StartTime) {
+			s.Restarted = true
+		}
+	}
+	if m.history != nil && m.history.restarted(key, s.StartTime) {
+		s.Restarted = true
+		m.history.reset(key)
+	}
+}
+
+// estimate refines the lifetime-average estimate of s with the options and
+// the history. A failing -eta-template is returned, leaving the built-in
+// ETA in place.
+func (m *monitor) estimate(s *decomStatus, now time.Time) error {
+	var peak int64
+	if m.history != nil {
+		peak = m.history.peak(stateKey(m.alias, s.CmdLine), s.StartTime)
+	}
+	s.clampRegression(peak)
+	if m.objectBasisTotal > 0 {
+		s.useObjectBasis(m.objectBasisTotal)
+	}
+	if m.bothBasisObjectTotal > 0 && !m.out.noETA {
+		s.applyObjectETA(m.bothBasisObjectTotal)
+	}
+	if m.out.noETA {
+		s.dropETA()
+	}
+	if m.history != nil {
+		key := stateKey(m.alias, s.CmdLine)
+		if !m.since.IsZero() {
+			if base, ok := m.history.firstSince(key, s.StartTime, m.since); ok {
+				s.applyWindow(base, now)
+			}
+		}
+		if base, ok := m.history.firstSince(key, s.StartTime, s.recentCutoff()); ok {
+			s.applyRecent(base, now)
+		}
+		s.applyRange(m.history.run(key, s.StartTime))
+		if m.historyPrior && !m.out.noETA {
+			if runs, speed, ok := m.history.clusterSpeed(m.alias, s.Basis); ok {
+				s.applyHistory(runs, speed)
+			}
+		}
+		if m.comparePrior && !m.out.noETA {
+			if pool, speed, ok := m.history.priorSpeed(m.alias, key, s.Basis); ok {
+				s.applyPrior(pool, speed)
+			}
+		}
+	}
+	if m.formula != nil {
+		return s.applyFormula(m.formula)
+	}
+	return nil
+}
+
+// dumpRaw writes the pools exactly as returned by the admin API, so they can
+// be attached to bug reports. A path of "-" means stdout.
+func dumpRaw(path string, pools []madmin.PoolStatus) error {
+	data, err := json.MarshalIndent(pools, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
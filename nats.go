@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsConn is a minimal publish-only NATS client. It speaks just enough of
+// the text protocol (CONNECT, PUB, PING/PONG) to push events without pulling
+// in a full client library.
+type natsConn struct {
+	addr string
+	u    *url.URL
+
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+	err  error // last -ERR received from the server
+}
+
+func newNATSConn(rawURL string) (*natsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse NATS URL %q: %w", rawURL, err)
+	}
+	switch u.Scheme {
+	case "nats", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported NATS URL scheme %q (want nats:// or tls://)", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &natsConn{addr: addr, u: u}, nil
+}
+
+func (nc *natsConn) connect() error {
+	conn, err := net.DialTimeout("tcp", nc.addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	if nc.u.Scheme == "tls" {
+		conn = tls.Client(conn, &tls.Config{ServerName: nc.u.Hostname()})
+	}
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("read INFO: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+
+	opts := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "decom-eta",
+		"lang":     "go",
+	}
+	if nc.u.User != nil {
+		if pass, ok := nc.u.User.Password(); ok {
+			opts["user"] = nc.u.User.Username()
+			opts["pass"] = pass
+		} else {
+			opts["auth_token"] = nc.u.User.Username()
+		}
+	}
+	connectJSON, err := json.Marshal(opts)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	// PING after CONNECT so authentication failures surface here rather
+	// than on the first publish.
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connectJSON); err != nil {
+		conn.Close()
+		return err
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("read CONNECT reply: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return fmt.Errorf("server rejected connection: %s", strings.TrimPrefix(line, "-ERR "))
+		}
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	nc.conn = conn
+	nc.w = bufio.NewWriter(conn)
+	nc.err = nil
+	go nc.readLoop(conn, r)
+	return nil
+}
+
+// readLoop answers server PINGs so idle connections are not dropped between
+// polls, and records protocol errors for the next publish to report.
+func (nc *natsConn) readLoop(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		nc.mu.Lock()
+		if nc.conn != conn {
+			nc.mu.Unlock()
+			return
+		}
+		if err != nil {
+			nc.close()
+			nc.mu.Unlock()
+			return
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PING":
+			nc.w.WriteString("PONG\r\n")
+			nc.w.Flush()
+		case strings.HasPrefix(line, "-ERR"):
+			nc.err = errors.New(strings.TrimPrefix(line, "-ERR "))
+		}
+		nc.mu.Unlock()
+	}
+}
+
+// close must be called with nc.mu held.
+func (nc *natsConn) close() {
+	if nc.conn != nil {
+		nc.conn.Close()
+		nc.conn = nil
+	}
+}
+
+// publish sends data on subject, connecting (or reconnecting) as needed.
+func (nc *natsConn) publish(subject string, data []byte) error {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	if nc.err != nil {
+		err := nc.err
+		nc.err = nil
+		nc.close()
+		return fmt.Errorf("nats: %w", err)
+	}
+	if nc.conn == nil {
+		if err := nc.connect(); err != nil {
+			return fmt.Errorf("nats connect %s: %w", nc.addr, err)
+		}
+	}
+
+	fmt.Fprintf(nc.w, "PUB %s %d\r\n", subject, len(data))
+	nc.w.Write(data)
+	nc.w.WriteString("\r\n")
+	if err := nc.w.Flush(); err != nil {
+		nc.close()
+		return fmt.Errorf("nats publish: %w", err)
+	}
+	return nil
+}
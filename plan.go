@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// stateQueued marks a planned pool whose decommission hasn't started.
+const stateQueued = "queued"
+
+// planStep is one pool of a -plan sequence.
+type planStep struct {
+	Pool     int // 1-based, as displayed
+	State    string
+	Found    bool
+	ToMove   int64         // bytes still used on the pool
+	Duration time.Duration // projected, when the plan has a speed
+}
+
+// planStatus projects a sequential multi-pool decommission using the speed
+// of the pool currently draining.
+type planStatus struct {
+	Steps     []planStep
+	Speed     float64 // bytes/sec; 0 while no drain has measurable progress
+	Remaining time.Duration
+}
+
+// parsePlan parses a comma-separated list of 1-based pool numbers.
+func parsePlan(s string) ([]int, error) {
+	var order []int
+	seen := map[int]bool{}
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(f), "#"))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid -plan %q: want pool numbers such as 1,2,3", s)
+		}
+		if seen[n] {
+			return nil, fmt.Errorf("invalid -plan %q: pool %d listed twice", s, n)
+		}
+		seen[n] = true
+		order = append(order, n)
+	}
+	return order, nil
+}
+
+func computePlan(order []int, pools []madmin.PoolStatus, statuses []decomStatus) *planStatus {
+	p := &planStatus{}
+
+	byID := map[int]decomStatus{}
+	for _, s := range statuses {
+		byID[s.ID+1] = s
+	}
+	// The first pool of the plan that is actually moving data sets the pace
+	// for everything after it. Plans are byte-based whatever -eta-basis is.
+	for _, n := range order {
+		if s, ok := byID[n]; ok && s.State == stateActive && s.HasProgress {
+			p.Speed = s.Speed
+			if s.Basis != basisBytes {
+				p.Speed = float64(s.BytesFreed) / s.Elapsed.Seconds()
+			}
+			break
+		}
+	}
+
+	for _, n := range order {
+		step := planStep{Pool: n, State: stateQueued}
+		for _, pool := range pools {
+			if pool.ID+1 != n {
+				continue
+			}
+			step.Found = true
+			if d := pool.Decommission; d != nil {
+				step.ToMove = d.TotalSize - d.CurrentSize
+				if !d.StartTime.IsZero() {
+					step.State = decomState(d)
+				}
+			}
+		}
+		if step.State == stateComplete {
+			step.ToMove = 0
+		}
+		if p.Speed > 0 && step.ToMove > 0 {
+			step.Duration = time.Duration(float64(step.ToMove)/p.Speed) * time.Second
+			p.Remaining += step.Duration
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	return p
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// presets are named bundles of flag defaults for common uses.
+var presets = map[string]map[string]string{
+	// Just the numbers: one line of progress and speed per cluster.
+	"minimal": {"summary-only": "true", "no-eta": "true"},
+	// Everything the API can tell about the cluster and its sets.
+	"detailed": {"verbose": "true", "show-server-info": "true"},
+	// For a terminal left open or captured to a log: timestamped polls,
+	// ETAs without false precision and one ETA for all the drains.
+	"ops": {"plain": "true", "round-eta": "true", "show-server-info": "true", "aggregate-mode": aggregateCombined},
+}
+
+// applyPreset sets the flags of the named preset, except those given
+// explicitly on the command line, which take precedence.
+func applyPreset(fs *flag.FlagSet, name string) error {
+	preset, ok := presets[name]
+	if !ok {
+		var names []string
+		for n := range presets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("invalid -preset %q: want one of %s", name, strings.Join(names, ", "))
+	}
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for flagName, value := range preset {
+		if explicit[flagName] {
+			continue
+		}
+		if err := fs.Set(flagName, value); err != nil {
+			return fmt.Errorf("-preset %s: %w", name, err)
+		}
+	}
+	return nil
+}
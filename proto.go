@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io"
+
+	"github.com/minio/decom-eta/statuspb"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newProtoReport converts a poll to its -proto message, by way of the JSON
+// form so that both carry the same fields.
+func newProtoReport(r *pollReport) *statuspb.Report {
+	msg := &statuspb.Report{Alias: r.Alias, Time: timestamppb.New(r.Time), RunId: r.RunID}
+	for _, p := range newJSONReport(r).Pools {
+		pool := &statuspb.Pool{
+			Alias:                 p.Alias,
+			Time:                  timestamppb.New(p.Time),
+			Id:                    int32(p.ID),
+			Cmdline:               p.CmdLine,
+			State:                 p.State,
+			StartTime:             timestamppb.New(p.StartTime),
+			ElapsedSeconds:        p.ElapsedSeconds,
+			TotalSize:             p.TotalSize,
+			InitialUsed:           p.InitialUsed,
+			BytesFreed:            p.BytesFreed,
+			UsedNow:               p.UsedNow,
+			ObjectsDone:           p.ObjectsDone,
+			ObjectsFailed:         p.ObjectsFailed,
+			Basis:                 p.Basis,
+			ProgressPercent:       p.ProgressPercent,
+			Speed:                 p.Speed,
+			EtaSeconds:            p.ETASeconds,
+			RecentSpeed:           p.RecentSpeed,
+			RecentEtaSeconds:      p.RecentETASeconds,
+			EtaLowSeconds:         p.ETALowSeconds,
+			EtaHighSeconds:        p.ETAHighSeconds,
+			PriorPool:             int32(p.PriorPool),
+			PriorEtaSeconds:       p.PriorETASeconds,
+			Restarted:             p.Restarted,
+			RegressedBytes:        p.RegressedBytes,
+			ObjectProgressPercent: p.ObjectProgressPercent,
+			ObjectEtaSeconds:      p.ObjectETASeconds,
+			EtaFromHistory:        p.ETAFromHistory,
+			RunId:                 p.RunID,
+		}
+		if p.ETA != nil {
+			pool.Eta = timestamppb.New(*p.ETA)
+		}
+		msg.Pools = append(msg.Pools, pool)
+	}
+	return msg
+}
+
+// writeProto writes a poll as a size-delimited statuspb.Report, so that the
+// polls of a watch can be read back one message at a time.
+func writeProto(w io.Writer, r *pollReport) error {
+	_, err := protodelim.MarshalTo(w, newProtoReport(r))
+	return err
+}
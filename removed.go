@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// removedPool is a pool the history file has samples of that the cluster no
+// longer lists: once a drained pool is taken out of the server command line,
+// its decommission status goes with it.
+type removedPool struct {
+	// Status is computed from the pool's last sample, as of when it was
+	// taken, so Elapsed runs up to the poll that saw the drain end.
+	Status   decomStatus
+	LastSeen time.Time
+}
+
+// removedPools returns the pools of alias in the history that are missing
+// from pools, the latest seen first.
+func (h *history) removedPools(alias string, pools []madmin.PoolStatus) []removedPool {
+	listed := map[string]bool{}
+	for _, pool := range pools {
+		listed[pool.CmdLine] = true
+	}
+	var out []removedPool
+	for _, samples := range h.samples {
+		if len(samples) == 0 {
+			continue
+		}
+		last := samples[len(samples)-1]
+		if last.Alias != alias || listed[last.CmdLine] {
+			continue
+		}
+		info := last.PoolDecommissionInfo
+		s, ok := computeStatus(madmin.PoolStatus{ID: last.Pool, CmdLine: last.CmdLine, Decommission: &info}, last.Time)
+		if !ok {
+			continue
+		}
+		out = append(out, removedPool{Status: s, LastSeen: last.Time})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeen.After(out[j].LastSeen) })
+	return out
+}
+
+// showRemoved runs -show-removed.
+func (m *monitor) showRemoved() ([]removedPool, error) {
+	pools, err := m.listPools()
+	if err != nil {
+		return nil, err
+	}
+	return m.history.removedPools(m.alias, pools), nil
+}
+
+// printRemoved prints the final stats of each removed pool. A pool whose
+// last sample isn't complete was removed before a poll saw its drain end,
+// possibly with data still on it, which is pointed out.
+func (o outputOptions) printRemoved(removed []removedPool, historyFile string) {
+	if len(removed) == 0 {
+		fmt.Printf("No removed pools in %s.\n", historyFile)
+		return
+	}
+	for i, p := range removed {
+		s := p.Status
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Removed pool (was #%d): %s\n", s.ID+1, o.poolLabel(s.CmdLine))
+		fmt.Printf("  Started: %s\n", s.StartTime.Format(time.RFC3339))
+		if s.State == stateComplete {
+			fmt.Printf("  Completed: by %s, after %s\n", p.LastSeen.Format(time.RFC3339), o.duration(s.Elapsed))
+		} else {
+			fmt.Printf("  Last seen: %s, decommission %s; it was removed without a poll seeing the drain complete\n",
+				p.LastSeen.Format(time.RFC3339), s.State)
+		}
+		line := fmt.Sprintf("  Moved: %s freed", o.ibytes(uint64(max(s.BytesFreed, 0))))
+		if s.InitialUsed > 0 {
+			line += fmt.Sprintf(" of %s (%s)", o.ibytes(uint64(s.InitialUsed)), o.percent(100*float64(s.BytesFreed)/float64(s.InitialUsed)))
+		}
+		line += fmt.Sprintf(", %s objects", o.comma(s.ObjectsDone))
+		if s.ObjectsFailed > 0 {
+			line += fmt.Sprintf(", %s failed", o.comma(s.ObjectsFailed))
+		}
+		fmt.Println(line)
+		if secs := s.Elapsed.Seconds(); secs > 0 && s.BytesFreed > 0 {
+			fmt.Printf("  Average speed: %s\n", o.formatSpeed(basisBytes, float64(s.BytesFreed)/secs))
+		}
+	}
+}
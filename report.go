@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// reporter is an output sink. Every enabled sink is fed the same report on
+// each poll.
+type reporter interface {
+	report(r *pollReport) error
+}
+
+// pollReport is the outcome of one poll.
+type pollReport struct {
+	Time  time.Time
+	Alias string
+	RunID string        // of this invocation, for -run-id
+	Pools []decomStatus // every pool that has been decommissioned at some point
+	// Listed and Kept count the pools the cluster reported and those left
+	// after -match/-exclude/-server, to tell an empty cluster response and
+	// an over-eager filter apart from "nothing draining".
+	Listed int
+	Kept   int
+	// ProgressHidden counts the decommissioned pools left out of Pools by
+	// -progress-above/-progress-below.
+	ProgressHidden int
+	Plan           *planStatus          // nil unless -plan
+	Server         *serverBanner        // nil unless -show-server-info
+	Sets           map[int][]setUsage   // by pool ID; nil unless -verbose
+	Drives         map[int][]driveUsage // by pool ID, gating drive first; nil unless -verbose
+	// Capacity lists receiving pools projected to run low on space.
+	Capacity []capacityWarning
+	// Cluster is the cluster's free space before and after the drains;
+	// nil when nothing is draining.
+	Cluster *clusterSpace
+	// Finished are the pools that were draining at the previous poll of a
+	// watch and have since completed, failed or been canceled.
+	Finished []decomStatus
+}
+
+// newRunID returns a random (version 4) UUID, the default -run-id.
+func newRunID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// active returns the pools that are currently draining.
+func (r *pollReport) active() []decomStatus {
+	var out []decomStatus
+	for _, s := range r.Pools {
+		if s.State == stateActive {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// changed returns the draining pools followed by those that just finished,
+// for the outputs that record each pool's last state.
+func (r *pollReport) changed() []decomStatus {
+	return append(r.active(), r.Finished...)
+}
+
+// jsonPool is the machine-readable form of a decomStatus. Estimates that are
+// not available yet are null rather than omitted, so consumers see a stable
+// schema.
+type jsonPool struct {
+	Alias            string     `json:"alias"`
+	Time             time.Time  `json:"time"`
+	RunID            string     `json:"runId"`
+	ID               int        `json:"id"`
+	CmdLine          string     `json:"cmdline"`
+	State            string     `json:"state"`
+	StartTime        time.Time  `json:"startTime"`
+	ElapsedSeconds   float64    `json:"elapsedSeconds"`
+	TotalSize        int64      `json:"totalSize"`
+	InitialUsed      int64      `json:"initialUsed"`
+	BytesFreed       int64      `json:"bytesFreed"`
+	UsedNow          int64      `json:"usedNow"`
+	ObjectsDone      int64      `json:"objectsDone"`
+	ObjectsFailed    int64      `json:"objectsFailed"`
+	Basis            string     `json:"basis"`
+	ProgressPercent  *float64   `json:"progressPercent"`
+	Speed            *float64   `json:"speed"`
+	ETASeconds       *float64   `json:"etaSeconds"`
+	ETA              *time.Time `json:"eta"`
+	RecentSpeed      *float64   `json:"recentSpeed"`
+	RecentETASeconds *float64   `json:"recentEtaSeconds"`
+	ETALowSeconds    *float64   `json:"etaLowSeconds"`
+	ETAHighSeconds   *float64   `json:"etaHighSeconds"`
+	PriorPool        int        `json:"priorPool"` // 0 unless priorEtaSeconds is set
+	PriorETASeconds  *float64   `json:"priorEtaSeconds"`
+	// The object-count estimate, with -eta-basis both.
+	ObjectProgressPercent *float64 `json:"objectProgressPercent"`
+	ObjectETASeconds      *float64 `json:"objectEtaSeconds"`
+	Restarted             bool     `json:"restarted"`
+	RegressedBytes        int64    `json:"regressedBytes"`
+	ETAFromHistory        bool     `json:"etaFromHistory"` // -history-prior
+}
+
+// compactPool is jsonPool for -compact-json: the same fields, with the ones
+// that are null or zero left out.
+type compactPool struct {
+	Alias                 string     `json:"alias,omitzero"`
+	Time                  time.Time  `json:"time,omitzero"`
+	RunID                 string     `json:"runId,omitzero"`
+	ID                    int        `json:"id,omitzero"`
+	CmdLine               string     `json:"cmdline,omitzero"`
+	State                 string     `json:"state,omitzero"`
+	StartTime             time.Time  `json:"startTime,omitzero"`
+	ElapsedSeconds        float64    `json:"elapsedSeconds,omitzero"`
+	TotalSize             int64      `json:"totalSize,omitzero"`
+	InitialUsed           int64      `json:"initialUsed,omitzero"`
+	BytesFreed            int64      `json:"bytesFreed,omitzero"`
+	UsedNow               int64      `json:"usedNow,omitzero"`
+	ObjectsDone           int64      `json:"objectsDone,omitzero"`
+	ObjectsFailed         int64      `json:"objectsFailed,omitzero"`
+	Basis                 string     `json:"basis,omitzero"`
+	ProgressPercent       *float64   `json:"progressPercent,omitzero"`
+	Speed                 *float64   `json:"speed,omitzero"`
+	ETASeconds            *float64   `json:"etaSeconds,omitzero"`
+	ETA                   *time.Time `json:"eta,omitzero"`
+	RecentSpeed           *float64   `json:"recentSpeed,omitzero"`
+	RecentETASeconds      *float64   `json:"recentEtaSeconds,omitzero"`
+	ETALowSeconds         *float64   `json:"etaLowSeconds,omitzero"`
+	ETAHighSeconds        *float64   `json:"etaHighSeconds,omitzero"`
+	PriorPool             int        `json:"priorPool,omitzero"`
+	PriorETASeconds       *float64   `json:"priorEtaSeconds,omitzero"`
+	ObjectProgressPercent *float64   `json:"objectProgressPercent,omitzero"`
+	ObjectETASeconds      *float64   `json:"objectEtaSeconds,omitzero"`
+	Restarted             bool       `json:"restarted,omitzero"`
+	RegressedBytes        int64      `json:"regressedBytes,omitzero"`
+	ETAFromHistory        bool       `json:"etaFromHistory,omitzero"`
+}
+
+// jsonReport is the document written by -json and posted by -webhook.
+type jsonReport struct {
+	Alias   string        `json:"alias"`
+	Time    time.Time     `json:"time"`
+	RunID   string        `json:"runId"`
+	Pools   []jsonPool    `json:"pools"`
+	Cluster *clusterSpace `json:"cluster"`
+}
+
+type compactReport struct {
+	Alias   string        `json:"alias"`
+	Time    time.Time     `json:"time"`
+	RunID   string        `json:"runId,omitzero"`
+	Pools   []compactPool `json:"pools"`
+	Cluster *clusterSpace `json:"cluster,omitzero"`
+}
+
+// jsonShape is how pools are encoded: -compact-json and -json-fields.
+type jsonShape struct {
+	compact bool
+	fields  map[string]bool // the jsonPool fields to keep; nil for all
+}
+
+// encodedPool returns what to marshal for p.
+func encodedPool(p jsonPool, shape jsonShape) any {
+	switch {
+	case shape.fields != nil:
+		return projectedPool{pool: p, shape: shape}
+	case shape.compact:
+		return compactPool(p)
+	}
+	return p
+}
+
+// encodedReport returns what to marshal for doc.
+func encodedReport(doc jsonReport, shape jsonShape) any {
+	if shape.fields != nil {
+		return projectedReport{doc: doc, shape: shape}
+	}
+	if !shape.compact {
+		return doc
+	}
+	out := compactReport{Alias: doc.Alias, Time: doc.Time, RunID: doc.RunID, Pools: []compactPool{}, Cluster: doc.Cluster}
+	for _, p := range doc.Pools {
+		out.Pools = append(out.Pools, compactPool(p))
+	}
+	return out
+}
+
+// projectedPool marshals only the -json-fields of a pool, in schema order.
+type projectedPool struct {
+	pool  jsonPool
+	shape jsonShape
+}
+
+func (p projectedPool) MarshalJSON() ([]byte, error) {
+	return marshalFields(reflect.ValueOf(p.pool), p.shape.compact, func(name string, v reflect.Value) (any, bool) {
+		return v.Interface(), p.shape.fields[name]
+	})
+}
+
+// projectedReport is a jsonReport whose pools are projected.
+type projectedReport struct {
+	doc   jsonReport
+	shape jsonShape
+}
+
+func (r projectedReport) MarshalJSON() ([]byte, error) {
+	return marshalFields(reflect.ValueOf(r.doc), r.shape.compact, func(name string, v reflect.Value) (any, bool) {
+		if name != "pools" {
+			return v.Interface(), true
+		}
+		pools := []projectedPool{}
+		for _, p := range r.doc.Pools {
+			pools = append(pools, projectedPool{pool: p, shape: r.shape})
+		}
+		return pools, true
+	})
+}
+
+// marshalFields encodes the struct v as a JSON object, with the value that
+// field returns for each of its fields it keeps. compact leaves out the
+// fields that are zero, as omitzero does.
+func marshalFields(v reflect.Value, compact bool, field func(name string, v reflect.Value) (any, bool)) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := range v.NumField() {
+		name := jsonName(v.Type().Field(i))
+		value, keep := field(name, v.Field(i))
+		if !keep || (compact && v.Field(i).IsZero()) {
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonName is the name a struct field is encoded under.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// parseJSONFields parses a -json-fields list, such as
+// "id,progressPercent,etaSeconds", against the fields of jsonPool.
+func parseJSONFields(s string) (map[string]bool, error) {
+	known := map[string]bool{}
+	var names []string
+	t := reflect.TypeFor[jsonPool]()
+	for i := range t.NumField() {
+		name := jsonName(t.Field(i))
+		known[name] = true
+		names = append(names, name)
+	}
+	fields := map[string]bool{}
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if !known[f] {
+			return nil, fmt.Errorf("invalid -json-fields: unknown field %q; the fields are %s", f, strings.Join(names, ", "))
+		}
+		fields[f] = true
+	}
+	return fields, nil
+}
+
+func newJSONPool(r *pollReport, s decomStatus) jsonPool {
+	now := r.Time
+	p := jsonPool{
+		Alias:          r.Alias,
+		Time:           now,
+		RunID:          r.RunID,
+		ID:             s.ID + 1,
+		CmdLine:        s.CmdLine,
+		State:          s.State,
+		StartTime:      s.StartTime,
+		ElapsedSeconds: s.Elapsed.Seconds(),
+		TotalSize:      s.TotalSize,
+		InitialUsed:    s.InitialUsed,
+		BytesFreed:     s.BytesFreed,
+		UsedNow:        s.UsedNow,
+		ObjectsDone:    s.ObjectsDone,
+		ObjectsFailed:  s.ObjectsFailed,
+		Basis:          s.Basis,
+		Restarted:      s.Restarted,
+		RegressedBytes: s.Regressed,
+		ETAFromHistory: s.HistoryRuns > 0,
+	}
+	if s.HasProgress {
+		progress, speed := s.Progress*100, s.Speed
+		p.ProgressPercent, p.Speed = &progress, &speed
+	}
+	if s.HasETA {
+		eta, at := s.ETA.Seconds(), now.Add(s.ETA)
+		p.ETASeconds, p.ETA = &eta, &at
+	}
+	if s.HasRecent {
+		speed, eta := s.RecentSpeed, s.RecentETA.Seconds()
+		p.RecentSpeed, p.RecentETASeconds = &speed, &eta
+	}
+	if s.HasRange {
+		low := s.ETALow.Seconds()
+		p.ETALowSeconds = &low
+		if s.ETAHigh > 0 {
+			high := s.ETAHigh.Seconds()
+			p.ETAHighSeconds = &high
+		}
+	}
+	if s.HasPrior {
+		eta := s.PriorETA.Seconds()
+		p.PriorPool, p.PriorETASeconds = s.PriorPool, &eta
+	}
+	if s.HasObjectETA {
+		progress, eta := 100*s.ObjectProgress, s.ObjectETA.Seconds()
+		p.ObjectProgressPercent, p.ObjectETASeconds = &progress, &eta
+	}
+	return p
+}
+
+func newJSONReport(r *pollReport) jsonReport {
+	doc := jsonReport{Alias: r.Alias, Time: r.Time, RunID: r.RunID, Pools: []jsonPool{}, Cluster: r.Cluster}
+	for _, s := range r.changed() {
+		doc.Pools = append(doc.Pools, newJSONPool(r, s))
+	}
+	return doc
+}
+
+// fileReporter appends one JSON line (or CSV row) per draining pool per
+// poll, building a log that outlives the process.
+type fileReporter struct {
+	path  string
+	shape jsonShape
+	csv   bool
+}
+
+func (f *fileReporter) report(r *pollReport) error {
+	if f.csv {
+		return appendCSV(f.path, r)
+	}
+	fh, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("output file: %w", err)
+	}
+	enc := json.NewEncoder(fh)
+	for _, s := range r.changed() {
+		if err := enc.Encode(encodedPool(newJSONPool(r, s), f.shape)); err != nil {
+			fh.Close()
+			return fmt.Errorf("output file: write %s: %w", f.path, err)
+		}
+	}
+	return fh.Close()
+}
+
+// teeReporter appends the -json document of every poll to a file, as one
+// line, so the JSON can be logged while the console shows the text.
+type teeReporter struct {
+	path  string
+	shape jsonShape
+}
+
+func (t *teeReporter) report(r *pollReport) error {
+	fh, err := os.OpenFile(t.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("tee JSON: %w", err)
+	}
+	if err := json.NewEncoder(fh).Encode(encodedReport(newJSONReport(r), t.shape)); err != nil {
+		fh.Close()
+		return fmt.Errorf("tee JSON: write %s: %w", t.path, err)
+	}
+	return fh.Close()
+}
+
+// webhookReporter POSTs the JSON report to a URL on every poll.
+type webhookReporter struct {
+	url    string
+	shape  jsonShape
+	client *http.Client
+}
+
+func newWebhookReporter(url string, shape jsonShape) *webhookReporter {
+	return &webhookReporter{url: url, shape: shape, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *webhookReporter) report(r *pollReport) error {
+	return postJSON(w.client, w.url, encodedReport(newJSONReport(r), w.shape))
+}
+
+// postJSON sends v as a JSON body and treats any non-2xx reply as an error.
+func postJSON(client *http.Client, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s returned %s", url, resp.Status)
+	}
+	return nil
+}
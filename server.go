@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// serverBanner is a one-line summary of the cluster, from ServerInfo.
+type serverBanner struct {
+	Versions []string // distinct release tags, sorted
+	Nodes    int
+	Offline  int
+}
+
+func (b serverBanner) String() string {
+	version := "unknown version"
+	switch len(b.Versions) {
+	case 0:
+	case 1:
+		version = b.Versions[0]
+	default:
+		version = "mixed versions " + strings.Join(b.Versions, ", ")
+	}
+	s := fmt.Sprintf("MinIO %s on %s", version, plural(b.Nodes, "node", "nodes"))
+	if b.Offline > 0 {
+		s += fmt.Sprintf(" (%d offline)", b.Offline)
+	}
+	return s
+}
+
+func fetchServerInfo(client *madmin.AdminClient) (madmin.InfoMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info, err := client.ServerInfo(ctx)
+	if err != nil {
+		if isAccessDenied(err) {
+			err = &accessDeniedError{err: err, actions: []string{"admin:ServerInfo"}}
+		}
+		return madmin.InfoMessage{}, fmt.Errorf("server info: %w", err)
+	}
+	return info, nil
+}
+
+func newServerBanner(info madmin.InfoMessage) serverBanner {
+	b := serverBanner{Nodes: len(info.Servers)}
+	seen := map[string]bool{}
+	for _, srv := range info.Servers {
+		if srv.State != "" && srv.State != "online" {
+			b.Offline++
+		}
+		if v := releaseTag(srv.Version); v != "" && !seen[v] {
+			seen[v] = true
+			b.Versions = append(b.Versions, v)
+		}
+	}
+	sort.Strings(b.Versions)
+	return b
+}
+
+// releaseTag turns the release time servers report (2024-05-10T01:41:38Z)
+// into the familiar RELEASE.2024-05-10T01-41-38Z form.
+func releaseTag(version string) string {
+	t, err := time.Parse(time.RFC3339, version)
+	if err != nil {
+		return version
+	}
+	return "RELEASE." + t.UTC().Format("2006-01-02T15-04-05Z")
+}
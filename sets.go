@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// setUsage is the space used on one erasure set of a pool. The admin API
+// reports no per-set decommission progress, but while a pool drains, a set
+// whose usage stays high is one that is lagging.
+type setUsage struct {
+	Set         int // 1-based
+	RawUsage    uint64
+	RawCapacity uint64
+	Usage       uint64 // logical: the objects' own size, without parity
+	Objects     uint64
+}
+
+// poolSets returns the erasure sets of every pool in info, keyed by pool ID
+// and ordered by set.
+func poolSets(info madmin.InfoMessage) map[int][]setUsage {
+	out := map[int][]setUsage{}
+	for pool, sets := range info.Pools {
+		for _, set := range sets {
+			out[pool] = append(out[pool], setUsage{
+				Set:         set.ID + 1,
+				RawUsage:    set.RawUsage,
+				RawCapacity: set.RawCapacity,
+				Usage:       set.Usage,
+				Objects:     set.ObjectsCount,
+			})
+		}
+		sort.Slice(out[pool], func(i, j int) bool { return out[pool][i].Set < out[pool][j].Set })
+	}
+	return out
+}
+
+// rawOverhead is how many bytes the sets store on their drives per byte of
+// object data: erasure coding adds parity shards, so a pool drains more raw
+// bytes than the logical size of the objects it moves. It is false when the
+// sets report no logical usage.
+func rawOverhead(sets []setUsage) (float64, bool) {
+	var raw, logical uint64
+	for _, set := range sets {
+		raw += set.RawUsage
+		logical += set.Usage
+	}
+	if raw == 0 || logical == 0 {
+		return 0, false
+	}
+	return float64(raw) / float64(logical), true
+}
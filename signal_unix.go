@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// histogramSignal asks a running watch for its speed histogram.
+var histogramSignal os.Signal = syscall.SIGUSR1
+
+// refreshSignal makes a -refresh-on-sighup watch poll right away.
+var refreshSignal os.Signal = syscall.SIGHUP
+
+// stopSignals end a watch gracefully.
+var stopSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// histogramSignal is nil: Windows has no signal to spare for it, so the
+// histogram is only printed when the watch ends.
+var histogramSignal os.Signal
+
+// refreshSignal is nil as well: there is no SIGHUP to refresh on.
+var refreshSignal os.Signal
+
+// stopSignals end a watch gracefully.
+var stopSignals = []os.Signal{os.Interrupt}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/madmin-go/v3"
+)
+
+// simulateFlag is the hidden mode that runs a synthetic drain through the
+// status and ETA logic, for checking the estimates against a known outcome
+// and for demos without a cluster. It must come first on the command line
+// and takes its own flags.
+const simulateFlag = "-simulate"
+
+// simEpoch is when every simulated drain begins; only offsets from it are
+// shown.
+var simEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// simStall is a stretch of a simulated drain during which nothing moves.
+type simStall struct {
+	at, length time.Duration
+}
+
+// simScenario describes a synthetic drain.
+type simScenario struct {
+	total    uint64
+	used     uint64
+	speed    float64 // bytes/sec
+	jitter   float64 // relative spread of each interval's speed
+	interval time.Duration
+	stalls   []simStall
+	restarts []time.Duration
+	seed     int64
+}
+
+// simStep is one poll of the simulated cluster.
+type simStep struct {
+	at   time.Duration // since the simulated drain began
+	pool madmin.PoolStatus
+}
+
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet(simulateFlag, flag.ExitOnError)
+	total := fs.String("total", "1TiB", "size of the simulated pool")
+	used := fs.String("used", "600GiB", "data on the pool when the drain starts")
+	speed := fs.String("speed", "100MiB", "average drain speed per second")
+	jitter := fs.Float64("jitter", 0.2, "relative random spread of the speed between polls (0 to 1)")
+	interval := fs.Duration("interval", 10*time.Minute, "simulated time between polls")
+	seed := fs.Int64("seed", 1, "random seed, for reproducible runs")
+	var stalls, restarts stringList
+	fs.Var(&stalls, "stall", "stop moving data at a time into the drain for a while, as start:length (e.g. 2h:30m); repeatable")
+	fs.Var(&restarts, "restart", "cancel and restart the drain at this time into it (e.g. 3h); repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [flags]\n", os.Args[0], simulateFlag)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	sc := simScenario{jitter: *jitter, interval: *interval, seed: *seed}
+	var err error
+	if sc.total, err = humanize.ParseBytes(*total); err != nil {
+		return fmt.Errorf("invalid -total: %w", err)
+	}
+	if sc.used, err = humanize.ParseBytes(*used); err != nil {
+		return fmt.Errorf("invalid -used: %w", err)
+	}
+	bps, err := humanize.ParseBytes(*speed)
+	if err != nil {
+		return fmt.Errorf("invalid -speed: %w", err)
+	}
+	sc.speed = float64(bps)
+	switch {
+	case sc.used == 0 || sc.used > sc.total:
+		return fmt.Errorf("invalid -used %s: want more than 0 and at most -total", *used)
+	case sc.speed <= 0:
+		return fmt.Errorf("invalid -speed %s: want more than 0", *speed)
+	case sc.jitter < 0 || sc.jitter > 1:
+		return fmt.Errorf("invalid -jitter %g: want 0 to 1", sc.jitter)
+	case sc.interval < time.Minute:
+		return fmt.Errorf("invalid -interval %s: want at least 1m", sc.interval)
+	}
+	for _, s := range stalls {
+		at, length, ok := strings.Cut(s, ":")
+		st := simStall{}
+		if ok {
+			st.at, err = time.ParseDuration(at)
+			if err == nil {
+				st.length, err = time.ParseDuration(length)
+			}
+		}
+		if !ok || err != nil {
+			return fmt.Errorf("invalid -stall %q: want start:length, e.g. 2h:30m", s)
+		}
+		if st.at < 0 || st.length <= 0 {
+			return fmt.Errorf("invalid -stall %q: want a start of 0 or more and a length of more than 0", s)
+		}
+		sc.stalls = append(sc.stalls, st)
+	}
+	for _, s := range restarts {
+		at, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid -restart %q: %w", s, err)
+		}
+		sc.restarts = append(sc.restarts, at)
+	}
+
+	steps, finish := sc.run()
+	if finish == 0 {
+		return fmt.Errorf("the simulated drain doesn't finish within %s; raise -speed or shorten the stalls", formatDuration(simHorizon))
+	}
+	m := &monitor{alias: "simulate", out: outputOptions{format: formatText, precision: 1}}
+	m.history, _ = loadHistory("")
+	m.history.warmup = 1
+	m.printSimulation(steps, finish)
+	return nil
+}
+
+// stalled reports whether the drain is standing still at t.
+func (sc simScenario) stalled(t time.Duration) bool {
+	for _, st := range sc.stalls {
+		if t >= st.at && t < st.at+st.length {
+			return true
+		}
+	}
+	return false
+}
+
+// simHorizon is how far into a simulated drain run gives up, so that one too
+// slow to finish can't keep it going forever.
+const simHorizon = 30 * 24 * time.Hour
+
+// run plays the scenario out until the pool is empty, returning a snapshot
+// per poll and when the last byte moved, or 0 if that is beyond simHorizon.
+func (sc simScenario) run() ([]simStep, time.Duration) {
+	rng := rand.New(rand.NewSource(sc.seed))
+	d := madmin.PoolDecommissionInfo{
+		StartTime:   simEpoch,
+		TotalSize:   int64(sc.total),
+		StartSize:   int64(sc.total - sc.used),
+		CurrentSize: int64(sc.total - sc.used),
+	}
+	restarts := append([]time.Duration(nil), sc.restarts...)
+
+	var steps []simStep
+	var finish time.Duration
+	for t := sc.interval; finish == 0 && t <= simHorizon; t += sc.interval {
+		rate := sc.speed * (1 + sc.jitter*(2*rng.Float64()-1))
+		// Move the interval's data a second at a time, so that stalls and
+		// the finish land where they belong rather than on a poll.
+		for sec := t - sc.interval; sec < t; sec += time.Second {
+			if sc.stalled(sec) {
+				continue
+			}
+			d.CurrentSize += int64(rate)
+			d.ObjectsDecommissioned = (d.CurrentSize - d.StartSize) / (1 << 20)
+			if d.CurrentSize >= d.TotalSize {
+				d.CurrentSize = d.TotalSize
+				d.Complete = true
+				finish = sec + time.Second
+				break
+			}
+		}
+		if len(restarts) > 0 && t >= restarts[0] && !d.Complete {
+			restarts = restarts[1:]
+			d.StartTime = simEpoch.Add(t)
+			d.StartSize = d.CurrentSize
+		}
+		steps = append(steps, simStep{at: t, pool: madmin.PoolStatus{
+			ID:           0,
+			CmdLine:      "http://sim{1...4}/data{1...4}",
+			Decommission: &madmin.PoolDecommissionInfo{},
+		}})
+		*steps[len(steps)-1].pool.Decommission = d
+	}
+	return steps, finish
+}
+
+// printSimulation runs the snapshots through the same logic as a watch and
+// compares each ETA with the actual time left.
+func (m *monitor) printSimulation(steps []simStep, finish time.Duration) {
+	fmt.Printf("%-9s %-8s %-16s %-9s %-9s %-19s %-9s %s\n",
+		"TIME", "PROGRESS", "SPEED", "ETA", "RECENT", "RANGE", "ACTUAL", "ERROR")
+	for _, step := range steps {
+		statuses := m.computeStatuses([]madmin.PoolStatus{step.pool}, simEpoch.Add(step.at))
+		if len(statuses) == 0 {
+			continue
+		}
+		s := statuses[0]
+		note := ""
+		if s.Restarted {
+			note = "  (restarted)"
+		}
+		if s.State == stateComplete {
+			fmt.Printf("%-9s complete, drained in %s%s\n", formatDuration(step.at), formatDuration(finish), note)
+			continue
+		}
+		progress, speed, eta, recent, rng, errPct := "-", "-", "-", "-", "-", "-"
+		if s.HasProgress {
+			progress = m.out.percent(s.Progress * 100)
+			speed = m.out.formatSpeed(s.Basis, s.Speed)
+		}
+		actual := finish - step.at
+		if s.HasETA {
+			eta = formatDuration(s.ETA)
+			errPct = fmt.Sprintf("%+.0f%%", 100*(s.ETA-actual).Seconds()/actual.Seconds())
+		}
+		if s.HasRecent {
+			recent = formatDuration(s.RecentETA)
+		}
+		if s.HasRange {
+			high := "unbounded"
+			if s.ETAHigh > 0 {
+				high = formatDuration(s.ETAHigh)
+			}
+			rng = formatDuration(s.ETALow) + " to " + high
+		}
+		fmt.Printf("%-9s %-8s %-16s %-9s %-9s %-19s %-9s %s%s\n",
+			formatDuration(step.at), progress, speed, eta, recent, rng, formatDuration(actual), errPct, note)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// sqliteSchema is created on first use. Times are RFC 3339 text in UTC,
+// which SQLite's date functions understand; estimates not available yet are
+// NULL.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS pool_status (
+	time               TEXT    NOT NULL,
+	alias              TEXT    NOT NULL,
+	pool               INTEGER NOT NULL,
+	cmdline            TEXT    NOT NULL,
+	state              TEXT    NOT NULL,
+	start_time         TEXT    NOT NULL,
+	elapsed_seconds    REAL    NOT NULL,
+	total_size         INTEGER NOT NULL,
+	initial_used       INTEGER NOT NULL,
+	bytes_freed        INTEGER NOT NULL,
+	used_now           INTEGER NOT NULL,
+	objects_done       INTEGER NOT NULL,
+	objects_failed     INTEGER NOT NULL,
+	basis              TEXT    NOT NULL,
+	progress_percent   REAL,
+	speed              REAL,
+	eta_seconds        REAL,
+	recent_speed       REAL,
+	recent_eta_seconds REAL
+);
+CREATE INDEX IF NOT EXISTS pool_status_by_pool ON pool_status (alias, pool, time);
+`
+
+// sqliteReporter inserts a row per draining or just finished pool per
+// poll into a SQLite database, for ad-hoc queries over a drain's history.
+type sqliteReporter struct {
+	path string
+	db   *sql.DB
+}
+
+func newSQLiteReporter(path string) (*sqliteReporter, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: open %s: %w", path, err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite: create schema in %s: %w", path, err)
+	}
+	return &sqliteReporter{path: path, db: db}, nil
+}
+
+func (s *sqliteReporter) report(r *pollReport) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+	defer tx.Rollback()
+	for _, st := range r.changed() {
+		p := newJSONPool(r, st)
+		_, err := tx.Exec(`INSERT INTO pool_status VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			p.Time.UTC().Format(time.RFC3339Nano), p.Alias, p.ID, p.CmdLine, p.State,
+			p.StartTime.UTC().Format(time.RFC3339Nano), p.ElapsedSeconds,
+			p.TotalSize, p.InitialUsed, p.BytesFreed, p.UsedNow, p.ObjectsDone, p.ObjectsFailed, p.Basis,
+			p.ProgressPercent, p.Speed, p.ETASeconds, p.RecentSpeed, p.RecentETASeconds)
+		if err != nil {
+			return fmt.Errorf("sqlite: insert into %s: %w", s.path, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// poolState is what we remember about a pool between invocations.
+type poolState struct {
+	CurrentSize int64     `json:"currentSize"`
+	Time        time.Time `json:"time"`
+	// StartTime identifies the decommission run; a different one means the
+	// drain was restarted and CurrentSize is not comparable.
+	StartTime time.Time `json:"startTime"`
+}
+
+// stateFile persists the last observed free space per alias+pool so that
+// -diff-since can report progress made since the previous run.
+type stateFile struct {
+	path  string
+	Pools map[string]poolState `json:"pools"`
+}
+
+func defaultStatePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("get cache dir: %w", err)
+	}
+	return filepath.Join(cacheDir, "decom-eta", "state.json"), nil
+}
+
+func loadState(path string) (*stateFile, error) {
+	if path == "" {
+		p, err := defaultStatePath()
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+
+	st := &stateFile{path: path, Pools: map[string]poolState{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if st.Pools == nil {
+		st.Pools = map[string]poolState{}
+	}
+	return st, nil
+}
+
+func (st *stateFile) save() error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(st.path), 0o755); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	// Write to a temp file first so an interrupted run never leaves a
+	// truncated state behind.
+	tmp := st.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	return os.Rename(tmp, st.path)
+}
+
+func stateKey(alias, cmdLine string) string {
+	return alias + "/" + cmdLine
+}
+
+// formatSince renders the time of the previous observation, omitting the
+// date when it was today.
+func formatSince(t time.Time) string {
+	now := time.Now()
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format("15:04")
+	}
+	return t.Format("Jan 2 15:04")
+}
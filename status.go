@@ -0,0 +1,438 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// ETA bases: what "progress" is measured in.
+const (
+	basisBytes   = "bytes"
+	basisObjects = "objects"
+)
+
+// etaBasisBoth is the -eta-basis that estimates in bytes and, alongside,
+// in objects.
+const etaBasisBoth = "both"
+
+// Decommission states derived from the madmin flags.
+const (
+	stateActive   = "active"
+	stateComplete = "complete"
+	stateFailed   = "failed"
+	stateCanceled = "canceled"
+)
+
+// decomStatus is the computed view of one pool's decommission at a point in
+// time. Everything that prints or publishes status works from this.
+type decomStatus struct {
+	ID        int
+	CmdLine   string
+	State     string
+	StartTime time.Time
+	Elapsed   time.Duration
+
+	TotalSize   int64
+	CurrentSize int64
+	InitialUsed int64
+	BytesFreed  int64
+	UsedNow     int64
+	BytesDone   int64 // moved, as the server counts it
+
+	ObjectsDone   int64
+	ObjectsFailed int64
+	TotalObjects  int64 // only known with -total-objects
+
+	// Basis is what Progress, the speeds and the ETAs are measured in;
+	// speeds are bytes/sec or objects/sec accordingly.
+	Basis string
+
+	// HasProgress is false while the drain is still warming up; Progress
+	// and Speed are only meaningful when it is set.
+	HasProgress bool
+	Progress    float64
+	Speed       float64
+
+	// HasETA is set when the drain has made partial progress.
+	HasETA bool
+	ETA    time.Duration
+
+	// WindowStart is set when Speed and ETA were computed from samples
+	// taken after this time rather than from the whole run.
+	WindowStart time.Time
+
+	// HasRecent is set when there is a sample from the last quarter of the
+	// run; RecentSpeed and RecentETA then reflect only that stretch.
+	HasRecent   bool
+	RecentSpeed float64
+	RecentETA   time.Duration
+
+	// HasRange is set when enough samples were taken in watch mode to
+	// bound the ETA: ETALow and ETAHigh assume the speed is one standard
+	// deviation of the sampled interval speeds above and below Speed.
+	// ETAHigh is 0 when the speeds vary so much that the slow end is a
+	// standstill, i.e. there is no pessimistic bound.
+	HasRange  bool
+	ETALow    time.Duration
+	ETAHigh   time.Duration
+	Intervals int // number of interval speeds behind the range
+
+	// HasPrior is set, with -compare-to-previous-pool, for a pool with no
+	// ETA of its own yet: PriorETA is the time left at PriorSpeed, the
+	// average speed of the last pool that completed, PriorPool (1-based).
+	HasPrior   bool
+	PriorPool  int
+	PriorSpeed float64
+	PriorETA   time.Duration
+
+	// HistoryRuns is set, with -history-prior, while the ETA leans on the
+	// cluster's past drains, HistoryRuns completed runs that averaged
+	// HistorySpeed: it stands in for the ETA of a drain with no progress
+	// yet, and is blended with the live speed until HasRange.
+	HistoryRuns  int
+	HistorySpeed float64
+
+	// HasObjectETA is set with -eta-basis both, for a bytes-basis status:
+	// ObjectProgress, ObjectSpeed and ObjectETA are the lifetime-average
+	// estimate in objects, to hold against the bytes one.
+	HasObjectETA   bool
+	ObjectProgress float64
+	ObjectSpeed    float64
+	ObjectETA      time.Duration
+
+	// Regressed is how much free space the pool has lost since its peak
+	// (at the start, or a previous sample of the run), when data moves back
+	// onto it or the API misreports. The sizes and estimates above are then
+	// held at the peak rather than going backwards.
+	Regressed int64
+
+	// Restarted is set on the first poll after the decommission was
+	// restarted, i.e. its start time changed since the previous sample.
+	Restarted bool
+}
+
+// minRangeIntervals is how many sampled intervals it takes before the
+// spread of their speeds says anything about the uncertainty.
+const minRangeIntervals = 3
+
+// recentFraction is the trailing share of the elapsed time used for the
+// recent-speed estimate.
+const recentFraction = 0.25
+
+func decomState(d *madmin.PoolDecommissionInfo) string {
+	switch {
+	case d.Complete:
+		return stateComplete
+	case d.Failed:
+		return stateFailed
+	case d.Canceled:
+		return stateCanceled
+	}
+	return stateActive
+}
+
+// computeStatus derives progress, speed and ETA for a pool. It returns false
+// for pools that have never been decommissioned.
+func computeStatus(pool madmin.PoolStatus, now time.Time) (decomStatus, bool) {
+	d := pool.Decommission
+	if d == nil || d.StartTime.IsZero() {
+		return decomStatus{}, false
+	}
+
+	// StartSize/CurrentSize = free bytes at decom start / now.
+	// As data moves off pool, free space increases: CurrentSize > StartSize.
+	// initialUsed = data that needs to move off.
+	// bytesFreed = free space gained so far.
+	s := decomStatus{
+		ID:            pool.ID,
+		CmdLine:       pool.CmdLine,
+		State:         decomState(d),
+		StartTime:     d.StartTime,
+		Elapsed:       now.Sub(d.StartTime),
+		TotalSize:     d.TotalSize,
+		CurrentSize:   d.CurrentSize,
+		InitialUsed:   d.TotalSize - d.StartSize,
+		BytesFreed:    d.CurrentSize - d.StartSize,
+		UsedNow:       d.TotalSize - d.CurrentSize,
+		BytesDone:     d.BytesDone,
+		ObjectsDone:   d.ObjectsDecommissioned,
+		ObjectsFailed: d.ObjectsDecommissionFailed,
+		Basis:         basisBytes,
+	}
+	s.estimate(float64(s.BytesFreed), float64(s.InitialUsed))
+	return s, true
+}
+
+// clampRegression holds the status at peak, the most free space seen
+// before, if the pool has less free space now. It must be called before
+// anything else adjusts the estimates.
+func (s *decomStatus) clampRegression(peak int64) {
+	startSize := s.TotalSize - s.InitialUsed
+	peak = max(peak, startSize)
+	if s.CurrentSize >= peak {
+		return
+	}
+	s.Regressed = peak - s.CurrentSize
+	s.CurrentSize = peak
+	s.BytesFreed = peak - startSize
+	s.UsedNow = s.TotalSize - peak
+	s.HasProgress, s.Progress, s.Speed = false, 0, 0
+	s.HasETA, s.ETA = false, 0
+	s.estimate(float64(s.BytesFreed), float64(s.InitialUsed))
+}
+
+// useObjectBasis switches progress, speed and ETA to object counts. The
+// admin API doesn't report how many objects a pool holds, so the total has
+// to come from the operator.
+func (s *decomStatus) useObjectBasis(totalObjects int64) {
+	s.Basis = basisObjects
+	s.TotalObjects = totalObjects
+	s.HasProgress, s.Progress, s.Speed = false, 0, 0
+	s.HasETA, s.ETA = false, 0
+	s.estimate(float64(s.ObjectsDone), float64(totalObjects))
+}
+
+// estimate applies the lifetime-average math: done out of total over the
+// elapsed time.
+func (s *decomStatus) estimate(done, total float64) {
+	if done > 0 && total > 0 && s.Elapsed.Seconds() > 10 {
+		s.HasProgress = true
+		s.Progress = done / total
+		s.Speed = done / s.Elapsed.Seconds()
+
+		if s.Progress > 0 && s.Progress < 1.0 {
+			totalEstimated := s.Elapsed.Seconds() / s.Progress
+			etaSeconds := totalEstimated - s.Elapsed.Seconds()
+			s.HasETA = true
+			s.ETA = time.Duration(etaSeconds) * time.Second
+		}
+	}
+}
+
+// applyPrior fills in a provisional ETA from the speed of a previously
+// drained pool, for a drain too new to have an estimate of its own.
+func (s *decomStatus) applyPrior(pool int, speed float64) {
+	remaining := s.remaining()
+	if s.HasETA || s.State != stateActive || speed <= 0 || remaining <= 0 {
+		return
+	}
+	s.HasPrior = true
+	s.PriorPool, s.PriorSpeed = pool, speed
+	s.PriorETA = time.Duration(remaining/speed) * time.Second
+}
+
+// historyPriorWeight is how much live progress the historical speed
+// counts for in applyHistory: after this long, the live speed has the
+// larger say.
+const historyPriorWeight = time.Hour
+
+// applyHistory bases the ETA of a drain whose live estimate hasn't settled
+// yet on speed, the average of runs past drains of the cluster. Its share
+// of the blended speed shrinks as the drain runs, and once there are
+// enough samples for an ETA range the live estimate is used alone.
+func (s *decomStatus) applyHistory(runs int, speed float64) {
+	remaining := s.remaining()
+	if s.HasRange || s.State != stateActive || speed <= 0 || remaining <= 0 {
+		return
+	}
+	blended := speed
+	if s.HasProgress {
+		live, prior := s.Elapsed.Seconds(), historyPriorWeight.Seconds()
+		blended = (s.Speed*live + speed*prior) / (live + prior)
+	}
+	s.HasETA = true
+	s.ETA = time.Duration(remaining/blended) * time.Second
+	s.HistoryRuns, s.HistorySpeed = runs, speed
+}
+
+// applyObjectETA adds the object-count estimate to a bytes-basis status.
+func (s *decomStatus) applyObjectETA(totalObjects int64) {
+	o := decomStatus{Elapsed: s.Elapsed}
+	o.estimate(float64(s.ObjectsDone), float64(totalObjects))
+	if !o.HasETA || s.State != stateActive {
+		return
+	}
+	s.HasObjectETA = true
+	s.ObjectProgress, s.ObjectSpeed, s.ObjectETA = o.Progress, o.Speed, o.ETA
+}
+
+// divergenceRatio is how far apart the bytes and object ETAs must be, as a
+// ratio, before divergence remarks on it.
+const divergenceRatio = 1.25
+
+// divergence explains a large gap between the bytes and object ETAs, or
+// returns "" when they roughly agree.
+func (s decomStatus) divergence() string {
+	if !s.HasETA || !s.HasObjectETA || s.ETA <= 0 {
+		return ""
+	}
+	switch ratio := s.ObjectETA.Seconds() / s.ETA.Seconds(); {
+	case ratio > divergenceRatio:
+		return "mostly small objects are left, so per-object overhead dominates"
+	case ratio < 1/divergenceRatio:
+		return "mostly large objects are left, so raw data volume dominates"
+	}
+	return ""
+}
+
+// dropETA discards the estimate for -no-eta. Everything derived from it
+// (the recent and range estimates) is skipped as a result.
+func (s *decomStatus) dropETA() {
+	s.HasETA, s.ETA = false, 0
+}
+
+// doneSince is the progress, in the status's basis, made since base.
+func (s decomStatus) doneSince(base sample) float64 {
+	if s.Basis == basisObjects {
+		return float64(s.ObjectsDone - base.ObjectsDecommissioned)
+	}
+	return float64(s.CurrentSize - base.CurrentSize)
+}
+
+// remaining is how much is left to move, in the status's basis. For bytes,
+// whatever is still used on the pool has to move off.
+func (s decomStatus) remaining() float64 {
+	if s.Basis == basisObjects {
+		return float64(s.TotalObjects - s.ObjectsDone)
+	}
+	return float64(s.UsedNow)
+}
+
+// applyWindow recomputes speed and ETA from the progress made since base,
+// so that a slow start (or a pause) no longer drags the estimate down.
+func (s *decomStatus) applyWindow(base sample, now time.Time) {
+	window := now.Sub(base.Time)
+	done := s.doneSince(base)
+	if !s.HasProgress || done <= 0 || window.Seconds() <= 10 {
+		return
+	}
+
+	s.WindowStart = base.Time
+	s.Speed = done / window.Seconds()
+	if s.HasETA {
+		s.ETA = time.Duration(s.remaining()/s.Speed) * time.Second
+	}
+}
+
+// recentCutoff is the start of the trailing window for applyRecent.
+func (s decomStatus) recentCutoff() time.Time {
+	return s.StartTime.Add(time.Duration(float64(s.Elapsed) * (1 - recentFraction)))
+}
+
+// applyRecent fills in the recent-window estimate from base, a sample taken
+// at or after recentCutoff.
+func (s *decomStatus) applyRecent(base sample, now time.Time) {
+	window := now.Sub(base.Time)
+	done := s.doneSince(base)
+	if !s.HasETA || done <= 0 || window.Seconds() <= 10 {
+		return
+	}
+
+	s.HasRecent = true
+	s.RecentSpeed = done / window.Seconds()
+	s.RecentETA = time.Duration(s.remaining()/s.RecentSpeed) * time.Second
+}
+
+// intervalSpeeds returns the speed, in the status's basis, between each pair
+// of consecutive samples, ignoring those before WindowStart when it is set.
+func (s decomStatus) intervalSpeeds(samples []sample) []float64 {
+	var speeds []float64
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		if cur.Time.Before(s.WindowStart) {
+			continue
+		}
+		dt := cur.Time.Sub(prev.Time).Seconds()
+		if dt <= 0 {
+			continue
+		}
+		done := float64(cur.CurrentSize - prev.CurrentSize)
+		if s.Basis == basisObjects {
+			done = float64(cur.ObjectsDecommissioned - prev.ObjectsDecommissioned)
+		}
+		speeds = append(speeds, done/dt)
+	}
+	return speeds
+}
+
+// applyRange derives the ETA range from the interval speeds of the run.
+func (s *decomStatus) applyRange(samples []sample) {
+	if !s.HasETA {
+		return
+	}
+	speeds := s.intervalSpeeds(samples)
+	if len(speeds) < minRangeIntervals {
+		return
+	}
+
+	var mean, sq float64
+	for _, v := range speeds {
+		mean += v
+	}
+	mean /= float64(len(speeds))
+	for _, v := range speeds {
+		sq += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(sq / float64(len(speeds)-1))
+
+	s.HasRange = true
+	s.Intervals = len(speeds)
+	s.ETALow = time.Duration(s.remaining()/(s.Speed+stddev)) * time.Second
+	s.ETAHigh = 0
+	if slow := s.Speed - stddev; slow > 0 {
+		s.ETAHigh = time.Duration(s.remaining()/slow) * time.Second
+	}
+}
+
+// trend compares the recent speed with the overall one.
+func (s decomStatus) trend() string {
+	switch ratio := s.RecentSpeed / s.Speed; {
+	case ratio > 1.05:
+		return "trending faster"
+	case ratio < 0.95:
+		return "trending slower"
+	}
+	return "steady"
+}
+
+// Values of -aggregate-mode.
+const (
+	aggregateMax      = "max"
+	aggregateCombined = "combined"
+)
+
+// aggregateETA is when every draining pool is expected to be done. With
+// aggregateMax that is the latest of their ETAs, which holds when the drains
+// don't slow each other down. aggregateCombined instead divides the data
+// left on all of them by their combined speed, as if they shared a single
+// limit (the receiving pools' drives, say) that would go to whichever pools
+// are still draining once the others finish. Each pool's speed is the one
+// its ETA is based on, remaining/ETA, which need not be Speed: a young
+// drain's ETA may come from the cluster history. It returns false unless
+// every draining pool has an ETA.
+func aggregateETA(active []decomStatus, mode string) (time.Duration, bool) {
+	var latest time.Duration
+	var remaining, speed float64
+	for _, s := range active {
+		if !s.HasETA {
+			return 0, false
+		}
+		latest = max(latest, s.ETA)
+		remaining += s.remaining()
+		if s.ETA > 0 {
+			speed += s.remaining() / s.ETA.Seconds()
+		}
+	}
+	if len(active) == 0 {
+		return 0, false
+	}
+	if mode == aggregateCombined {
+		if speed <= 0 {
+			return 0, false
+		}
+		return time.Duration(remaining/speed) * time.Second, true
+	}
+	return latest, true
+}
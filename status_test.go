@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// draining is an active bytes-basis status with used bytes left and, when
+// eta is set, an ETA at speed.
+func draining(used int64, speed float64, eta time.Duration) decomStatus {
+	return decomStatus{
+		State:   stateActive,
+		Basis:   basisBytes,
+		UsedNow: used,
+		Speed:   speed,
+		HasETA:  eta > 0,
+		ETA:     eta,
+	}
+}
+
+func TestAggregateETA(t *testing.T) {
+	tests := []struct {
+		name   string
+		active []decomStatus
+		mode   string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"no pools", nil, aggregateMax, 0, false},
+		{"a pool without an ETA", []decomStatus{draining(3600, 1, time.Hour), draining(3600, 0, 0)}, aggregateMax, 0, false},
+		{"max is the latest ETA", []decomStatus{draining(3600, 1, time.Hour), draining(7200, 1, 2*time.Hour)}, aggregateMax, 2 * time.Hour, true},
+		{"combined shares the speed", []decomStatus{draining(3600, 1, time.Hour), draining(7200, 2, time.Hour)}, aggregateCombined, time.Hour, true},
+		{"combined frees the fast pool's speed", []decomStatus{draining(3600, 1, time.Hour), draining(3600, 3, 20*time.Minute)}, aggregateCombined, 30 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := aggregateETA(tt.active, tt.mode)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("aggregateETA(%s) = %s, %t, want %s, %t", tt.mode, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClampRegression(t *testing.T) {
+	const total, start = 1000, 400 // 600 bytes to move
+	tests := []struct {
+		name                       string
+		current, peak              int64
+		wantCurrent, wantRegressed int64
+		wantFreed, wantUsed        int64
+	}{
+		{"progressing", 700, 650, 700, 0, 300, 300},
+		{"at the peak", 700, 700, 700, 0, 300, 300},
+		{"below the peak", 600, 700, 700, 100, 300, 300},
+		{"below the start without samples", 350, 0, 400, 50, 0, 600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := decomStatus{
+				State:       stateActive,
+				Basis:       basisBytes,
+				Elapsed:     time.Hour,
+				TotalSize:   total,
+				CurrentSize: tt.current,
+				InitialUsed: total - start,
+				BytesFreed:  tt.current - start,
+				UsedNow:     total - tt.current,
+			}
+			s.estimate(float64(s.BytesFreed), float64(s.InitialUsed))
+			s.clampRegression(tt.peak)
+			if s.CurrentSize != tt.wantCurrent || s.Regressed != tt.wantRegressed || s.BytesFreed != tt.wantFreed || s.UsedNow != tt.wantUsed {
+				t.Errorf("got current %d, regressed %d, freed %d, used %d; want %d, %d, %d, %d",
+					s.CurrentSize, s.Regressed, s.BytesFreed, s.UsedNow, tt.wantCurrent, tt.wantRegressed, tt.wantFreed, tt.wantUsed)
+			}
+			if s.HasProgress != (tt.wantFreed > 0) {
+				t.Errorf("HasProgress = %t with %d freed", s.HasProgress, tt.wantFreed)
+			}
+			if want := float64(tt.wantFreed) / (total - start); s.HasProgress && s.Progress != want {
+				t.Errorf("Progress = %g, want %g", s.Progress, want)
+			}
+		})
+	}
+}
+
+func TestApplyHistory(t *testing.T) {
+	fresh := draining(3600, 0, 0)
+	live := draining(3600, 3, 20*time.Minute)
+	live.HasProgress, live.Elapsed = true, time.Hour
+	settled := live
+	settled.HasRange = true
+	done := fresh
+	done.State = stateComplete
+
+	tests := []struct {
+		name     string
+		s        decomStatus
+		speed    float64
+		wantETA  time.Duration
+		wantRuns int
+	}{
+		{"no progress yet: the history speed", fresh, 1, time.Hour, 3},
+		{"blended with an hour of live progress", live, 1, 30 * time.Minute, 3},
+		{"settled: the live estimate alone", settled, 1, 20 * time.Minute, 0},
+		{"no history speed", fresh, 0, 0, 0},
+		{"not draining", done, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.s
+			s.applyHistory(3, tt.speed)
+			if s.ETA != tt.wantETA || s.HasETA != (tt.wantETA > 0) || s.HistoryRuns != tt.wantRuns {
+				t.Errorf("got ETA %s (HasETA %t), %d runs; want %s, %d runs", s.ETA, s.HasETA, s.HistoryRuns, tt.wantETA, tt.wantRuns)
+			}
+		})
+	}
+}
+
+// A drain whose ETA comes from the history has no live speed of its own;
+// the combined ETA must use the speed that ETA implies.
+func TestAggregateETAHistorySpeed(t *testing.T) {
+	fresh := draining(7200, 0, 0)
+	fresh.applyHistory(2, 1) // 2h at 1 byte/sec
+	active := []decomStatus{fresh, draining(3600, 1, time.Hour)}
+	got, ok := aggregateETA(active, aggregateCombined)
+	if want := 5400 * time.Second; !ok || got != want {
+		t.Errorf("aggregateETA(combined) = %s, %t, want %s, true", got, ok, want)
+	}
+
+	stopped := draining(3600, 0, 0)
+	stopped.HasETA = true // an ETA of 0 implies no speed
+	if got, ok := aggregateETA([]decomStatus{stopped}, aggregateCombined); ok {
+		t.Errorf("aggregateETA(combined) with no speed = %s, true, want false", got)
+	}
+}
@@ -0,0 +1,493 @@
+// The status decom-eta computes, for -proto output. Regenerate status.pb.go
+// with protoc-gen-go after editing:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative statuspb/status.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: statuspb/status.proto
+
+package statuspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Report is one poll of a cluster: the -proto form of the -json document.
+type Report struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Alias string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// The pools being decommissioned.
+	Pools         []*Pool `protobuf:"bytes,3,rep,name=pools,proto3" json:"pools,omitempty"`
+	RunId         string  `protobuf:"bytes,4,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"` // -run-id
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Report) Reset() {
+	*x = Report{}
+	mi := &file_statuspb_status_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Report) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Report) ProtoMessage() {}
+
+func (x *Report) ProtoReflect() protoreflect.Message {
+	mi := &file_statuspb_status_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Report.ProtoReflect.Descriptor instead.
+func (*Report) Descriptor() ([]byte, []int) {
+	return file_statuspb_status_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Report) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *Report) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Report) GetPools() []*Pool {
+	if x != nil {
+		return x.Pools
+	}
+	return nil
+}
+
+func (x *Report) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+// Pool is the status of one draining pool, field for field the -jsonl
+// object. Estimates that are not available yet are unset.
+type Pool struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Alias            string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Time             *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Id               int32                  `protobuf:"varint,3,opt,name=id,proto3" json:"id,omitempty"` // 1-based
+	Cmdline          string                 `protobuf:"bytes,4,opt,name=cmdline,proto3" json:"cmdline,omitempty"`
+	State            string                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	StartTime        *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	ElapsedSeconds   float64                `protobuf:"fixed64,7,opt,name=elapsed_seconds,json=elapsedSeconds,proto3" json:"elapsed_seconds,omitempty"`
+	TotalSize        int64                  `protobuf:"varint,8,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	InitialUsed      int64                  `protobuf:"varint,9,opt,name=initial_used,json=initialUsed,proto3" json:"initial_used,omitempty"`
+	BytesFreed       int64                  `protobuf:"varint,10,opt,name=bytes_freed,json=bytesFreed,proto3" json:"bytes_freed,omitempty"`
+	UsedNow          int64                  `protobuf:"varint,11,opt,name=used_now,json=usedNow,proto3" json:"used_now,omitempty"`
+	ObjectsDone      int64                  `protobuf:"varint,12,opt,name=objects_done,json=objectsDone,proto3" json:"objects_done,omitempty"`
+	ObjectsFailed    int64                  `protobuf:"varint,13,opt,name=objects_failed,json=objectsFailed,proto3" json:"objects_failed,omitempty"`
+	Basis            string                 `protobuf:"bytes,14,opt,name=basis,proto3" json:"basis,omitempty"` // "bytes" or "objects"
+	ProgressPercent  *float64               `protobuf:"fixed64,15,opt,name=progress_percent,json=progressPercent,proto3,oneof" json:"progress_percent,omitempty"`
+	Speed            *float64               `protobuf:"fixed64,16,opt,name=speed,proto3,oneof" json:"speed,omitempty"` // bytes/sec, or objects/sec by basis
+	EtaSeconds       *float64               `protobuf:"fixed64,17,opt,name=eta_seconds,json=etaSeconds,proto3,oneof" json:"eta_seconds,omitempty"`
+	Eta              *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=eta,proto3" json:"eta,omitempty"`
+	RecentSpeed      *float64               `protobuf:"fixed64,19,opt,name=recent_speed,json=recentSpeed,proto3,oneof" json:"recent_speed,omitempty"`
+	RecentEtaSeconds *float64               `protobuf:"fixed64,20,opt,name=recent_eta_seconds,json=recentEtaSeconds,proto3,oneof" json:"recent_eta_seconds,omitempty"`
+	EtaLowSeconds    *float64               `protobuf:"fixed64,21,opt,name=eta_low_seconds,json=etaLowSeconds,proto3,oneof" json:"eta_low_seconds,omitempty"`
+	EtaHighSeconds   *float64               `protobuf:"fixed64,22,opt,name=eta_high_seconds,json=etaHighSeconds,proto3,oneof" json:"eta_high_seconds,omitempty"`
+	PriorPool        int32                  `protobuf:"varint,23,opt,name=prior_pool,json=priorPool,proto3" json:"prior_pool,omitempty"` // 0 unless prior_eta_seconds is set
+	PriorEtaSeconds  *float64               `protobuf:"fixed64,24,opt,name=prior_eta_seconds,json=priorEtaSeconds,proto3,oneof" json:"prior_eta_seconds,omitempty"`
+	Restarted        bool                   `protobuf:"varint,25,opt,name=restarted,proto3" json:"restarted,omitempty"`
+	// The object-count estimate, with -eta-basis both.
+	ObjectProgressPercent *float64 `protobuf:"fixed64,26,opt,name=object_progress_percent,json=objectProgressPercent,proto3,oneof" json:"object_progress_percent,omitempty"`
+	ObjectEtaSeconds      *float64 `protobuf:"fixed64,27,opt,name=object_eta_seconds,json=objectEtaSeconds,proto3,oneof" json:"object_eta_seconds,omitempty"`
+	RegressedBytes        int64    `protobuf:"varint,28,opt,name=regressed_bytes,json=regressedBytes,proto3" json:"regressed_bytes,omitempty"`
+	// Set while eta_seconds leans on the cluster's past drains (-history-prior).
+	EtaFromHistory bool   `protobuf:"varint,29,opt,name=eta_from_history,json=etaFromHistory,proto3" json:"eta_from_history,omitempty"`
+	RunId          string `protobuf:"bytes,30,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Pool) Reset() {
+	*x = Pool{}
+	mi := &file_statuspb_status_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pool) ProtoMessage() {}
+
+func (x *Pool) ProtoReflect() protoreflect.Message {
+	mi := &file_statuspb_status_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pool.ProtoReflect.Descriptor instead.
+func (*Pool) Descriptor() ([]byte, []int) {
+	return file_statuspb_status_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Pool) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *Pool) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Pool) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Pool) GetCmdline() string {
+	if x != nil {
+		return x.Cmdline
+	}
+	return ""
+}
+
+func (x *Pool) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Pool) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *Pool) GetElapsedSeconds() float64 {
+	if x != nil {
+		return x.ElapsedSeconds
+	}
+	return 0
+}
+
+func (x *Pool) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *Pool) GetInitialUsed() int64 {
+	if x != nil {
+		return x.InitialUsed
+	}
+	return 0
+}
+
+func (x *Pool) GetBytesFreed() int64 {
+	if x != nil {
+		return x.BytesFreed
+	}
+	return 0
+}
+
+func (x *Pool) GetUsedNow() int64 {
+	if x != nil {
+		return x.UsedNow
+	}
+	return 0
+}
+
+func (x *Pool) GetObjectsDone() int64 {
+	if x != nil {
+		return x.ObjectsDone
+	}
+	return 0
+}
+
+func (x *Pool) GetObjectsFailed() int64 {
+	if x != nil {
+		return x.ObjectsFailed
+	}
+	return 0
+}
+
+func (x *Pool) GetBasis() string {
+	if x != nil {
+		return x.Basis
+	}
+	return ""
+}
+
+func (x *Pool) GetProgressPercent() float64 {
+	if x != nil && x.ProgressPercent != nil {
+		return *x.ProgressPercent
+	}
+	return 0
+}
+
+func (x *Pool) GetSpeed() float64 {
+	if x != nil && x.Speed != nil {
+		return *x.Speed
+	}
+	return 0
+}
+
+func (x *Pool) GetEtaSeconds() float64 {
+	if x != nil && x.EtaSeconds != nil {
+		return *x.EtaSeconds
+	}
+	return 0
+}
+
+func (x *Pool) GetEta() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Eta
+	}
+	return nil
+}
+
+func (x *Pool) GetRecentSpeed() float64 {
+	if x != nil && x.RecentSpeed != nil {
+		return *x.RecentSpeed
+	}
+	return 0
+}
+
+func (x *Pool) GetRecentEtaSeconds() float64 {
+	if x != nil && x.RecentEtaSeconds != nil {
+		return *x.RecentEtaSeconds
+	}
+	return 0
+}
+
+func (x *Pool) GetEtaLowSeconds() float64 {
+	if x != nil && x.EtaLowSeconds != nil {
+		return *x.EtaLowSeconds
+	}
+	return 0
+}
+
+func (x *Pool) GetEtaHighSeconds() float64 {
+	if x != nil && x.EtaHighSeconds != nil {
+		return *x.EtaHighSeconds
+	}
+	return 0
+}
+
+func (x *Pool) GetPriorPool() int32 {
+	if x != nil {
+		return x.PriorPool
+	}
+	return 0
+}
+
+func (x *Pool) GetPriorEtaSeconds() float64 {
+	if x != nil && x.PriorEtaSeconds != nil {
+		return *x.PriorEtaSeconds
+	}
+	return 0
+}
+
+func (x *Pool) GetRestarted() bool {
+	if x != nil {
+		return x.Restarted
+	}
+	return false
+}
+
+func (x *Pool) GetObjectProgressPercent() float64 {
+	if x != nil && x.ObjectProgressPercent != nil {
+		return *x.ObjectProgressPercent
+	}
+	return 0
+}
+
+func (x *Pool) GetObjectEtaSeconds() float64 {
+	if x != nil && x.ObjectEtaSeconds != nil {
+		return *x.ObjectEtaSeconds
+	}
+	return 0
+}
+
+func (x *Pool) GetRegressedBytes() int64 {
+	if x != nil {
+		return x.RegressedBytes
+	}
+	return 0
+}
+
+func (x *Pool) GetEtaFromHistory() bool {
+	if x != nil {
+		return x.EtaFromHistory
+	}
+	return false
+}
+
+func (x *Pool) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+var File_statuspb_status_proto protoreflect.FileDescriptor
+
+const file_statuspb_status_proto_rawDesc = "" +
+	"\n" +
+	"\x15statuspb/status.proto\x12\bdecometa\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8b\x01\n" +
+	"\x06Report\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12$\n" +
+	"\x05pools\x18\x03 \x03(\v2\x0e.decometa.PoolR\x05pools\x12\x15\n" +
+	"\x06run_id\x18\x04 \x01(\tR\x05runId\"\xb5\n" +
+	"\n" +
+	"\x04Pool\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\x05R\x02id\x12\x18\n" +
+	"\acmdline\x18\x04 \x01(\tR\acmdline\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\tR\x05state\x129\n" +
+	"\n" +
+	"start_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x12'\n" +
+	"\x0felapsed_seconds\x18\a \x01(\x01R\x0eelapsedSeconds\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\b \x01(\x03R\ttotalSize\x12!\n" +
+	"\finitial_used\x18\t \x01(\x03R\vinitialUsed\x12\x1f\n" +
+	"\vbytes_freed\x18\n" +
+	" \x01(\x03R\n" +
+	"bytesFreed\x12\x19\n" +
+	"\bused_now\x18\v \x01(\x03R\ausedNow\x12!\n" +
+	"\fobjects_done\x18\f \x01(\x03R\vobjectsDone\x12%\n" +
+	"\x0eobjects_failed\x18\r \x01(\x03R\robjectsFailed\x12\x14\n" +
+	"\x05basis\x18\x0e \x01(\tR\x05basis\x12.\n" +
+	"\x10progress_percent\x18\x0f \x01(\x01H\x00R\x0fprogressPercent\x88\x01\x01\x12\x19\n" +
+	"\x05speed\x18\x10 \x01(\x01H\x01R\x05speed\x88\x01\x01\x12$\n" +
+	"\veta_seconds\x18\x11 \x01(\x01H\x02R\n" +
+	"etaSeconds\x88\x01\x01\x12,\n" +
+	"\x03eta\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\x03eta\x12&\n" +
+	"\frecent_speed\x18\x13 \x01(\x01H\x03R\vrecentSpeed\x88\x01\x01\x121\n" +
+	"\x12recent_eta_seconds\x18\x14 \x01(\x01H\x04R\x10recentEtaSeconds\x88\x01\x01\x12+\n" +
+	"\x0feta_low_seconds\x18\x15 \x01(\x01H\x05R\retaLowSeconds\x88\x01\x01\x12-\n" +
+	"\x10eta_high_seconds\x18\x16 \x01(\x01H\x06R\x0eetaHighSeconds\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"prior_pool\x18\x17 \x01(\x05R\tpriorPool\x12/\n" +
+	"\x11prior_eta_seconds\x18\x18 \x01(\x01H\aR\x0fpriorEtaSeconds\x88\x01\x01\x12\x1c\n" +
+	"\trestarted\x18\x19 \x01(\bR\trestarted\x12;\n" +
+	"\x17object_progress_percent\x18\x1a \x01(\x01H\bR\x15objectProgressPercent\x88\x01\x01\x121\n" +
+	"\x12object_eta_seconds\x18\x1b \x01(\x01H\tR\x10objectEtaSeconds\x88\x01\x01\x12'\n" +
+	"\x0fregressed_bytes\x18\x1c \x01(\x03R\x0eregressedBytes\x12(\n" +
+	"\x10eta_from_history\x18\x1d \x01(\bR\x0eetaFromHistory\x12\x15\n" +
+	"\x06run_id\x18\x1e \x01(\tR\x05runIdB\x13\n" +
+	"\x11_progress_percentB\b\n" +
+	"\x06_speedB\x0e\n" +
+	"\f_eta_secondsB\x0f\n" +
+	"\r_recent_speedB\x15\n" +
+	"\x13_recent_eta_secondsB\x12\n" +
+	"\x10_eta_low_secondsB\x13\n" +
+	"\x11_eta_high_secondsB\x14\n" +
+	"\x12_prior_eta_secondsB\x1a\n" +
+	"\x18_object_progress_percentB\x15\n" +
+	"\x13_object_eta_secondsB%Z#github.com/minio/decom-eta/statuspbb\x06proto3"
+
+var (
+	file_statuspb_status_proto_rawDescOnce sync.Once
+	file_statuspb_status_proto_rawDescData []byte
+)
+
+func file_statuspb_status_proto_rawDescGZIP() []byte {
+	file_statuspb_status_proto_rawDescOnce.Do(func() {
+		file_statuspb_status_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_statuspb_status_proto_rawDesc), len(file_statuspb_status_proto_rawDesc)))
+	})
+	return file_statuspb_status_proto_rawDescData
+}
+
+var file_statuspb_status_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_statuspb_status_proto_goTypes = []any{
+	(*Report)(nil),                // 0: decometa.Report
+	(*Pool)(nil),                  // 1: decometa.Pool
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_statuspb_status_proto_depIdxs = []int32{
+	2, // 0: decometa.Report.time:type_name -> google.protobuf.Timestamp
+	1, // 1: decometa.Report.pools:type_name -> decometa.Pool
+	2, // 2: decometa.Pool.time:type_name -> google.protobuf.Timestamp
+	2, // 3: decometa.Pool.start_time:type_name -> google.protobuf.Timestamp
+	2, // 4: decometa.Pool.eta:type_name -> google.protobuf.Timestamp
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_statuspb_status_proto_init() }
+func file_statuspb_status_proto_init() {
+	if File_statuspb_status_proto != nil {
+		return
+	}
+	file_statuspb_status_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_statuspb_status_proto_rawDesc), len(file_statuspb_status_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_statuspb_status_proto_goTypes,
+		DependencyIndexes: file_statuspb_status_proto_depIdxs,
+		MessageInfos:      file_statuspb_status_proto_msgTypes,
+	}.Build()
+	File_statuspb_status_proto = out.File
+	file_statuspb_status_proto_goTypes = nil
+	file_statuspb_status_proto_depIdxs = nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// Transitions recorded by -event-log. A finished drain is logged under its
+// final state: complete, failed or canceled.
+const (
+	transitionStarted       = "started"
+	transitionFirstProgress = "firstProgress"
+	transitionMilestone     = "milestone"
+	transitionStalled       = "stalled"
+	transitionResumed       = "resumed"
+)
+
+// stallAfter is how long a draining pool must go without progress, across
+// polls, before -event-log calls it stalled.
+const stallAfter = 5 * time.Minute
+
+// transition is one line of the -event-log.
+type transition struct {
+	Time        time.Time `json:"time"`
+	RunID       string    `json:"runId"`
+	Alias       string    `json:"alias"`
+	Pool        int       `json:"pool"`
+	CmdLine     string    `json:"cmdline"`
+	Event       string    `json:"event"`
+	State       string    `json:"state"`
+	BytesFreed  int64     `json:"bytesFreed"`
+	ObjectsDone int64     `json:"objectsDone"`
+	Progress    *float64  `json:"progress,omitempty"`
+	Milestone   int       `json:"milestone,omitempty"` // percent, for milestone
+	// StalledSeconds is how long the drain stood still, for resumed.
+	StalledSeconds *float64 `json:"stalledSeconds,omitempty"`
+}
+
+// timelinePool is what the -event-log remembers of a pool between polls.
+type timelinePool struct {
+	startTime   time.Time
+	state       string
+	hasProgress bool
+	milestone   int // last 10% step reached
+	done        float64
+	lastMoved   time.Time // when done last changed
+	stalled     bool
+}
+
+// timelineReporter appends a JSON line for each transition it observes to a
+// file, or to stdout for "-". Transitions are inferred from successive polls,
+// so they are timed to the poll that saw them, except for the start, which
+// the cluster reports.
+type timelineReporter struct {
+	path  string
+	pools map[string]*timelinePool // keyed by pool CmdLine
+}
+
+func newTimelineReporter(path string) *timelineReporter {
+	return &timelineReporter{path: path, pools: map[string]*timelinePool{}}
+}
+
+func (t *timelineReporter) report(r *pollReport) error {
+	var events []transition
+	for _, s := range r.Pools {
+		events = append(events, t.observe(r, s)...)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if t.path != "-" {
+		fh, err := os.OpenFile(t.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("event log: %w", err)
+		}
+		defer fh.Close()
+		w = fh
+	}
+	enc := json.NewEncoder(w)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return fmt.Errorf("event log: write %s: %w", t.path, err)
+		}
+	}
+	return nil
+}
+
+// observe compares a pool's status with what was seen of it before and
+// returns the transitions in between.
+func (t *timelineReporter) observe(r *pollReport, s decomStatus) []transition {
+	now := r.Time
+	newTransition := func(name string, at time.Time) transition {
+		ev := transition{
+			Time:        at,
+			RunID:       r.RunID,
+			Alias:       r.Alias,
+			Pool:        s.ID + 1,
+			CmdLine:     s.CmdLine,
+			Event:       name,
+			State:       s.State,
+			BytesFreed:  s.BytesFreed,
+			ObjectsDone: s.ObjectsDone,
+		}
+		if s.HasProgress {
+			progress := s.Progress
+			ev.Progress = &progress
+		}
+		return ev
+	}
+	done := float64(s.BytesFreed)
+	if s.Basis == basisObjects {
+		done = float64(s.ObjectsDone)
+	}
+	milestone := 0
+	if s.HasProgress {
+		milestone = int(math.Floor(s.Progress*10)) * 10
+	}
+
+	p, seen := t.pools[s.CmdLine]
+	if !seen || !p.startTime.Equal(s.StartTime) {
+		// Only a drain seen running has transitions to log; what it did
+		// before the first poll counts as the starting point.
+		p = &timelinePool{startTime: s.StartTime, state: s.State, hasProgress: s.HasProgress,
+			milestone: milestone, done: done, lastMoved: now}
+		t.pools[s.CmdLine] = p
+		if s.State != stateActive {
+			return nil
+		}
+		return []transition{newTransition(transitionStarted, s.StartTime)}
+	}
+
+	var events []transition
+	if s.State != p.state {
+		p.state = s.State
+		if s.State != stateActive {
+			return append(events, newTransition(s.State, now))
+		}
+	}
+	if s.State != stateActive {
+		return nil
+	}
+	if s.HasProgress && !p.hasProgress {
+		p.hasProgress = true
+		events = append(events, newTransition(transitionFirstProgress, now))
+	}
+	switch {
+	case done != p.done:
+		if p.stalled {
+			ev := newTransition(transitionResumed, now)
+			stalled := now.Sub(p.lastMoved).Seconds()
+			ev.StalledSeconds = &stalled
+			events = append(events, ev)
+			p.stalled = false
+		}
+		p.done, p.lastMoved = done, now
+	case !p.stalled && now.Sub(p.lastMoved) >= stallAfter:
+		p.stalled = true
+		events = append(events, newTransition(transitionStalled, now))
+	}
+
+	for m := p.milestone + 10; m <= milestone && m < 100; m += 10 {
+		ev := newTransition(transitionMilestone, now)
+		ev.Milestone = m
+		events = append(events, ev)
+	}
+	p.milestone = max(p.milestone, milestone)
+	return events
+}
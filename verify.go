@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// verifyResult is the -verify verdict on one decommissioned pool.
+type verifyResult struct {
+	ID       int
+	CmdLine  string
+	State    string
+	Residual int64 // bytes still used on the pool
+	// FailedObjects and FailedBytes are what the drain couldn't move.
+	FailedObjects int64
+	FailedBytes   int64
+	Pass          bool
+}
+
+// verifyPools checks that every decommissioned pool finished its drain and
+// was left (close to) empty: no more than maxResidual bytes still used and
+// no objects that failed to move.
+func verifyPools(pools []madmin.PoolStatus, maxResidual int64) []verifyResult {
+	var results []verifyResult
+	for _, pool := range pools {
+		d := pool.Decommission
+		if d == nil || d.StartTime.IsZero() {
+			continue
+		}
+		r := verifyResult{
+			ID:            pool.ID,
+			CmdLine:       pool.CmdLine,
+			State:         decomState(d),
+			Residual:      max(d.TotalSize-d.CurrentSize, 0),
+			FailedObjects: d.ObjectsDecommissionFailed,
+			FailedBytes:   d.BytesFailed,
+		}
+		r.Pass = r.State == stateComplete && r.Residual <= maxResidual && r.FailedObjects == 0
+		results = append(results, r)
+	}
+	return results
+}
+
+// verify runs -verify against the pools that pass the filters.
+func (m *monitor) verify(maxResidual int64) ([]verifyResult, error) {
+	pools, err := m.listPools()
+	if err != nil {
+		return nil, err
+	}
+	return verifyPools(m.filter.apply(pools), maxResidual), nil
+}
+
+// printVerify prints a pass or fail line per pool and reports whether they
+// all passed. Having no decommissioned pool to check counts as a failure.
+func (o outputOptions) printVerify(results []verifyResult, maxResidual int64) bool {
+	if len(results) == 0 {
+		fmt.Println("No decommissioned pools to verify.")
+		return false
+	}
+	passed := true
+	for _, r := range results {
+		verdict := "pass"
+		if !r.Pass {
+			verdict, passed = "FAIL", false
+		}
+		line := fmt.Sprintf("Pool #%d: %s: decommission %s", r.ID+1, verdict, r.State)
+		if r.State != stateComplete {
+			line += ", not complete"
+		}
+		line += fmt.Sprintf(", %s still used (limit %s)", o.ibytes(uint64(r.Residual)), o.ibytes(uint64(maxResidual)))
+		if r.FailedObjects > 0 {
+			line += fmt.Sprintf(", %s objects (%s) failed to move", o.comma(r.FailedObjects), o.ibytes(uint64(r.FailedBytes)))
+		}
+		fmt.Println(line)
+	}
+	return passed
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// Time between polls of a healthy cluster: the default, and the least
+// -interval accepts unless minIntervalEnv lowers it, so that a typo doesn't
+// flood the admin API.
+const (
+	defaultPollInterval = 10 * time.Second
+	minPollInterval     = time.Second
+	minIntervalEnv      = "DECOM_ETA_MIN_INTERVAL"
+)
+
+// watchOptions controls the polling loop.
+type watchOptions struct {
+	maxErrors int
+	plain     bool
+	heartbeat time.Duration
+	interval  time.Duration // between polls
+	// waitAll stops the loop once every pool draining at the first poll
+	// has finished.
+	waitAll bool
+	// histogram prints the interval speed distribution when the watch
+	// ends, and on histogramSignal.
+	histogram bool
+	// maxRetryDelay caps the exponential backoff between failed polls.
+	maxRetryDelay time.Duration
+	// reportWebhook, with waitAll, receives a finalReport when the drains
+	// are over.
+	reportWebhook string
+	// refreshOnSignal polls as soon as refreshSignal arrives, rather than
+	// at the end of the interval.
+	refreshOnSignal bool
+	// reconnect builds a fresh client after a transport error.
+	reconnect func() (*madmin.AdminClient, error)
+}
+
+// watch polls until maxErrors consecutive polls fail or, with waitAll, the
+// drains it waits for are over.
+func (m *monitor) watch(opts watchOptions) error {
+	errCount := 0
+	var waiting map[string]int // CmdLine -> pool number, nil until the first poll
+	var lastHeartbeat time.Time
+	final := newFinalTracker(time.Now())
+
+	sigs := make(chan os.Signal, 1)
+	if opts.histogram {
+		signal.Notify(sigs, stopSignals...)
+		if histogramSignal != nil {
+			signal.Notify(sigs, histogramSignal)
+		}
+	}
+	if opts.refreshOnSignal {
+		signal.Notify(sigs, refreshSignal)
+	}
+	defer signal.Stop(sigs)
+
+	for {
+		switch {
+		case m.out.quiet, m.out.follow, m.out.format != formatText:
+			// Nothing to redraw: machine-readable and -follow output is
+			// appended.
+		case opts.plain:
+			// Delimit polls instead of redrawing so the output can be
+			// appended to a log as is.
+			fmt.Printf("--- %s ---\n", time.Now().Format(time.RFC3339))
+		default:
+			fmt.Print("\033[H\033[2J")
+		}
+		if err := m.poll(); err != nil {
+			errCount++
+			m.metrics.failed(err)
+			fmt.Fprintf(os.Stderr, "%s: poll failed (%d consecutive, next try in %s): %v\n",
+				time.Now().Format(time.RFC3339), errCount, retryDelay(errCount, opts.interval, opts.maxRetryDelay), err)
+			if opts.maxErrors > 0 && errCount >= opts.maxErrors {
+				return fmt.Errorf("giving up after %d consecutive failures", errCount)
+			}
+			// API errors mean the server answered; anything else is a
+			// transport problem, so start over with a fresh connection.
+			var apiErr madmin.ErrorResponse
+			if !errors.As(err, &apiErr) {
+				if c, err := opts.reconnect(); err == nil {
+					m.client = c
+				}
+			}
+		} else {
+			errCount = 0
+			if m.alert.tripped() {
+				return errETAExceeded
+			}
+			if opts.heartbeat > 0 && time.Since(lastHeartbeat) >= opts.heartbeat {
+				m.printHeartbeat()
+				lastHeartbeat = time.Now()
+			}
+			if opts.waitAll {
+				if waiting == nil {
+					waiting = map[string]int{}
+					for _, s := range m.last {
+						if s.State == stateActive {
+							waiting[s.CmdLine] = s.ID + 1
+						}
+					}
+				}
+				final.update(waiting, m.last, time.Now())
+				if done, err := m.waitDone(waiting); done {
+					if opts.histogram {
+						m.printHistograms(os.Stderr)
+					}
+					if opts.reportWebhook != "" {
+						if err := postFinalReport(opts.reportWebhook, final.report(m.alias, time.Now())); err != nil {
+							fmt.Fprintf(os.Stderr, "Warning: final report: %v\n", err)
+						}
+					}
+					return err
+				}
+			}
+		}
+
+		timer := time.After(retryDelay(errCount, opts.interval, opts.maxRetryDelay))
+	wait:
+		for {
+			select {
+			case <-timer:
+				break wait
+			case sig := <-sigs:
+				if sig == refreshSignal {
+					// The next interval counts from this poll.
+					break wait
+				}
+				m.printHistograms(os.Stderr)
+				if sig != histogramSignal {
+					return errInterrupted
+				}
+			}
+		}
+	}
+}
+
+// retryDelay is how long to wait after errCount consecutive failed polls:
+// interval, doubled for each failure after the first, up to limit.
+func retryDelay(errCount int, interval, limit time.Duration) time.Duration {
+	d := interval
+	for i := 1; i < errCount && d < limit; i++ {
+		d *= 2
+	}
+	return max(interval, min(d, limit))
+}
+
+// errInterrupted ends a watch stopped by a signal it handles.
+var errInterrupted = errors.New("interrupted")
+
+// waitDone reports whether none of the waiting pools is draining any more,
+// and if so, an error naming the ones that didn't complete. A pool that is
+// no longer listed is taken as complete: it has been removed from the
+// deployment.
+func (m *monitor) waitDone(waiting map[string]int) (bool, error) {
+	var failed []string
+	for cmdLine, n := range waiting {
+		for _, s := range m.last {
+			if s.CmdLine != cmdLine {
+				continue
+			}
+			switch s.State {
+			case stateActive:
+				return false, nil
+			case stateComplete:
+			default:
+				failed = append(failed, fmt.Sprintf("pool #%d %s", n, s.State))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return true, fmt.Errorf("decommission did not complete: %s", strings.Join(failed, ", "))
+	}
+	return true, nil
+}
+
+// heartbeatEvent is the -jsonl form of a heartbeat, telling consumers of an
+// -only-changes stream that it is still live.
+type heartbeatEvent struct {
+	Heartbeat bool      `json:"heartbeat"`
+	Alias     string    `json:"alias"`
+	Time      time.Time `json:"time"`
+	RunID     string    `json:"runId"`
+	Draining  int       `json:"draining"`
+}
+
+// printHeartbeat confirms a quiet watcher is still polling, with the
+// progress of each draining pool.
+func (m *monitor) printHeartbeat() {
+	if m.out.format == formatJSONL {
+		draining := 0
+		for _, s := range m.last {
+			if s.State == stateActive {
+				draining++
+			}
+		}
+		json.NewEncoder(os.Stdout).Encode(heartbeatEvent{Heartbeat: true, Alias: m.alias, Time: time.Now(), RunID: m.runID, Draining: draining})
+		return
+	}
+	var parts []string
+	for _, s := range m.last {
+		if s.State != stateActive {
+			continue
+		}
+		if s.HasProgress {
+			parts = append(parts, fmt.Sprintf("pool #%d %s", s.ID+1, m.out.percent(s.Progress*100)))
+		} else {
+			parts = append(parts, fmt.Sprintf("pool #%d starting", s.ID+1))
+		}
+	}
+	progress := "no pools draining"
+	if len(parts) > 0 {
+		progress = strings.Join(parts, ", ")
+	}
+	fmt.Printf("%s heartbeat: watching %s, %s\n", time.Now().Format(time.RFC3339), m.alias, progress)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		errCount int
+		interval time.Duration
+		limit    time.Duration
+		want     time.Duration
+	}{
+		{"no failures", 0, 10 * time.Second, 5 * time.Minute, 10 * time.Second},
+		{"first failure", 1, 10 * time.Second, 5 * time.Minute, 10 * time.Second},
+		{"second failure doubles", 2, 10 * time.Second, 5 * time.Minute, 20 * time.Second},
+		{"fourth failure", 4, 10 * time.Second, 5 * time.Minute, 80 * time.Second},
+		{"capped at the limit", 10, 10 * time.Second, 5 * time.Minute, 5 * time.Minute},
+		{"many failures don't overflow", 1000, 10 * time.Second, 5 * time.Minute, 5 * time.Minute},
+		{"limit below interval: no backoff", 5, time.Minute, 10 * time.Second, time.Minute},
+		{"limit equal to interval: no backoff", 5, time.Minute, time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.errCount, tt.interval, tt.limit); got != tt.want {
+				t.Errorf("retryDelay(%d, %s, %s) = %s, want %s", tt.errCount, tt.interval, tt.limit, got, tt.want)
+			}
+		})
+	}
+}